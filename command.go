@@ -1015,6 +1015,9 @@ func (raw signerInput) ReadUTXOKeys(hash crypto.Hash, index uint) (*common.UTXOK
 	}
 	utxo.Keys = out.Keys
 	utxo.Mask = out.Mask
+	utxo.Script = out.Script
+	utxo.Asset = out.Asset
+	utxo.Amount = out.Amount
 	return utxo, nil
 }
 
@@ -3,6 +3,7 @@ package common
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -19,6 +20,15 @@ type Address struct {
 	PublicViewKey   crypto.Key
 }
 
+// ViewAddress is the watch-only part of an Address, the private view key is
+// enough to recognize owned outputs with ViewGhostKey or OwnedOutputs, but
+// never to spend them.
+type ViewAddress struct {
+	PublicSpendKey crypto.Key
+	PublicViewKey  crypto.Key
+	PrivateViewKey crypto.Key
+}
+
 func NewAddressFromSeed(seed []byte) Address {
 	hash1 := crypto.Sha256Hash(seed)
 	hash2 := crypto.Sha256Hash(hash1[:])
@@ -67,6 +77,58 @@ func (a Address) String() string {
 	return MainAddressPrefix + base58.Encode(data)
 }
 
+func (a *Address) ViewOnly() *ViewAddress {
+	return &ViewAddress{
+		PublicSpendKey: a.PublicSpendKey,
+		PublicViewKey:  a.PublicViewKey,
+		PrivateViewKey: a.PrivateViewKey,
+	}
+}
+
+func (va *ViewAddress) Address() Address {
+	return Address{
+		PublicSpendKey: va.PublicSpendKey,
+		PublicViewKey:  va.PublicViewKey,
+		PrivateViewKey: va.PrivateViewKey,
+	}
+}
+
+// ExportWatchOnly encodes the public spend key and the private view key only.
+func (a *Address) ExportWatchOnly() []byte {
+	return append(a.PublicSpendKey[:], a.PrivateViewKey[:]...)
+}
+
+func ImportWatchOnly(b []byte) (*ViewAddress, error) {
+	if len(b) != 64 {
+		return nil, fmt.Errorf("invalid watch only size %d", len(b))
+	}
+	va := &ViewAddress{}
+	copy(va.PublicSpendKey[:], b[:32])
+	if !va.PublicSpendKey.CheckKey() {
+		return nil, errors.New("invalid watch only public spend key")
+	}
+	copy(va.PrivateViewKey[:], b[32:])
+	if !va.PrivateViewKey.HasValue() {
+		return nil, errors.New("invalid watch only private view key")
+	}
+	va.PublicViewKey = va.PrivateViewKey.Public()
+	return va, nil
+}
+
+// checkSigner rejects the watch-only addresses of ViewAddress.Address, they
+// have no private spend key of the public spend key, which is not required by
+// the signing, so an address of the private keys only is a signer.
+func (a *Address) checkSigner() error {
+	if !a.PrivateSpendKey.HasValue() && a.PrivateSpendKey.Public() != a.PublicSpendKey {
+		return fmt.Errorf("watch-only %s, cannot sign", a.String())
+	}
+	return nil
+}
+
+func (va *ViewAddress) String() string {
+	return va.Address().String()
+}
+
 func (a Address) Hash() crypto.Hash {
 	return crypto.Sha256Hash(append(a.PublicSpendKey[:], a.PublicViewKey[:]...))
 }
@@ -3,6 +3,7 @@ package common
 import (
 	"testing"
 
+	"github.com/MixinNetwork/mixin/crypto"
 	"github.com/stretchr/testify/require"
 )
 
@@ -46,6 +47,38 @@ func TestAddress(t *testing.T) {
 	require.Equal("0000000000000000000000000000000000000000000000000000000000000000", b.PrivateSpendKey.String())
 	require.Equal("013ada6acca01c3ba1fce30afa922a029bb224d4ab158127428b9e85c7175c32", b.Hash().String())
 
+	c := NewAddressFromSeed(seed)
+	va := c.ViewOnly()
+	require.Equal(addr, va.String())
+	require.Equal(c.PublicSpendKey, va.PublicSpendKey)
+	require.Equal(c.PrivateViewKey, va.PrivateViewKey)
+	require.Equal(crypto.Key{}, va.Address().PrivateSpendKey)
+
+	r := randomAccount()
+	tx := NewTransactionV5(XINAssetId)
+	tx.AddScriptOutput([]*Address{&r, &c}, NewThresholdScript(1), NewInteger(1), seed)
+	outputs := tx.ViewGhostKey(&va.PrivateViewKey)
+	require.Len(outputs, 1)
+	require.Equal(va.PublicSpendKey, *outputs[0].Keys[1])
+	require.Equal([]int{0}, tx.OwnedOutputs(&va.PrivateViewKey, &va.PublicSpendKey))
+	tx.AddOutputWithType(OutputTypeNodeRemove, []*Address{&r}, NewThresholdScript(1), NewInteger(1), seed)
+	tx.AddScriptOutput([]*Address{&c}, NewThresholdScript(1), NewInteger(1), seed)
+	require.Equal([]*crypto.Key{tx.Outputs[0].Keys[0], tx.Outputs[0].Keys[1], tx.Outputs[2].Keys[0]}, tx.AllOutputKeys())
+
+	wb := c.ExportWatchOnly()
+	require.Len(wb, 64)
+	wa, err := ImportWatchOnly(wb)
+	require.Nil(err)
+	require.Equal(va, wa)
+	require.Equal([]int{0, 2}, tx.OwnedOutputs(&wa.PrivateViewKey, &wa.PublicSpendKey))
+	_, err = ImportWatchOnly(wb[:63])
+	require.NotNil(err)
+	_, err = ImportWatchOnly(append(wb[:32:32], make([]byte, 32)...))
+	require.NotNil(err)
+	wo := wa.Address()
+	require.Contains(wo.checkSigner().Error(), "watch-only")
+	require.Nil(c.checkSigner())
+
 	z := NewAddressFromSeed(make([]byte, 64))
 	require.Equal("XIN8b7CsqwqaBP7576hvWzo7uDgbU9TB5KGU4jdgYpQTi2qrQGpBtrW49ENQiLGNrYU45e2wwKRD7dEUPtuaJYps2jbR4dH", z.String())
 	err = a.UnmarshalJSON([]byte("\"\""))
@@ -0,0 +1,407 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"filippo.io/edwards25519"
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+// AggregateSession coordinates a distributed aggregate signature, each signer
+// commits to and reveals its nonce, then contributes a partial share without
+// exposing the private key, and the coordinator combines all the shares.
+type AggregateSession struct {
+	message     crypto.Hash
+	publics     []*crypto.Key
+	signers     []int
+	commitments map[int]crypto.Hash
+	randoms     map[int]*crypto.Key
+}
+
+func (signed *SignedTransaction) NewAggregateSession(reader UTXOKeysReader, signers []int) (*AggregateSession, error) {
+	var publics []*crypto.Key
+	for _, in := range signed.Inputs {
+		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
+		if err != nil {
+			return nil, err
+		}
+		if utxo == nil {
+			return nil, fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
+		}
+		publics = append(publics, utxo.Keys...)
+	}
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("invalid signers count %d", len(signers))
+	}
+	for i, m := range signers {
+		if m < 0 || m >= len(publics) {
+			return nil, fmt.Errorf("invalid signer index %d/%d", m, len(publics))
+		}
+		if i > 0 && m <= signers[i-1] {
+			return nil, fmt.Errorf("invalid signers order %d %d", signers[i-1], m)
+		}
+	}
+
+	return &AggregateSession{
+		message:     signed.AsVersioned().PayloadHash(),
+		publics:     publics,
+		signers:     append([]int{}, signers...),
+		commitments: make(map[int]crypto.Hash),
+		randoms:     make(map[int]*crypto.Key),
+	}, nil
+}
+
+func AggregateNonceCommitment(R crypto.Key) crypto.Hash {
+	return crypto.Blake3Hash(R[:])
+}
+
+func (s *AggregateSession) Commit(m int, commitment crypto.Hash) error {
+	if !s.isSigner(m) {
+		return fmt.Errorf("invalid session signer %d", m)
+	}
+	if _, found := s.commitments[m]; found {
+		return fmt.Errorf("duplicate session commitment %d", m)
+	}
+	s.commitments[m] = commitment
+	return nil
+}
+
+func (s *AggregateSession) Reveal(m int, R crypto.Key) error {
+	if len(s.commitments) != len(s.signers) {
+		return fmt.Errorf("session commitments not ready %d %d", len(s.commitments), len(s.signers))
+	}
+	commitment, found := s.commitments[m]
+	if !found {
+		return fmt.Errorf("invalid session signer %d", m)
+	}
+	if AggregateNonceCommitment(R) != commitment {
+		return fmt.Errorf("invalid session nonce %d %s", m, R)
+	}
+	if !R.CheckKey() {
+		return fmt.Errorf("invalid session nonce format %d %s", m, R)
+	}
+	s.randoms[m] = &R
+	return nil
+}
+
+// Share returns the partial signature x * y + r of the signer m, with priv the
+// ghost private key of the signer and r the private nonce revealed before.
+func (s *AggregateSession) Share(m int, priv, r *crypto.Key) (*crypto.Key, error) {
+	R, found := s.randoms[m]
+	if !found {
+		return nil, fmt.Errorf("session nonce not revealed %d", m)
+	}
+	if r.Public() != *R {
+		return nil, fmt.Errorf("invalid session nonce %d %s", m, R)
+	}
+	if priv.Public() != *s.publics[m] {
+		return nil, fmt.Errorf("invalid session key %d %s", m, s.publics[m])
+	}
+	x, _, err := s.challenge()
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := edwards25519.NewScalar().SetCanonicalBytes(priv[:])
+	if err != nil {
+		return nil, err
+	}
+	z, err := edwards25519.NewScalar().SetCanonicalBytes(r[:])
+	if err != nil {
+		return nil, err
+	}
+	var share crypto.Key
+	copy(share[:], edwards25519.NewScalar().MultiplyAdd(x, y, z).Bytes())
+	return &share, nil
+}
+
+func (s *AggregateSession) Combine(shares map[int]*crypto.Key) (*AggregatedSignature, error) {
+	if len(shares) != len(s.signers) {
+		return nil, fmt.Errorf("session shares not ready %d %d", len(shares), len(s.signers))
+	}
+	x, P, err := s.challenge()
+	if err != nil {
+		return nil, err
+	}
+
+	S := edwards25519.NewScalar()
+	for _, m := range s.signers {
+		share, found := shares[m]
+		if !found {
+			return nil, fmt.Errorf("session share missing %d", m)
+		}
+		z, err := edwards25519.NewScalar().SetCanonicalBytes(share[:])
+		if err != nil {
+			return nil, err
+		}
+		R, err := edwards25519.NewIdentityPoint().SetBytes(s.randoms[m][:])
+		if err != nil {
+			return nil, err
+		}
+		a, err := edwards25519.NewIdentityPoint().SetBytes(s.publics[m][:])
+		if err != nil {
+			return nil, err
+		}
+		// z * B == R + x * A
+		lhs := edwards25519.NewIdentityPoint().ScalarBaseMult(z)
+		rhs := edwards25519.NewIdentityPoint().ScalarMult(x, a)
+		rhs = rhs.Add(rhs, R)
+		if lhs.Equal(rhs) != 1 {
+			return nil, fmt.Errorf("invalid session share %d", m)
+		}
+		S = S.Add(S, z)
+	}
+
+	as := &AggregatedSignature{Signers: append([]int{}, s.signers...)}
+	copy(as.Signature[:32], P.Bytes())
+	copy(as.Signature[32:], S.Bytes())
+	return as, nil
+}
+
+func (s *AggregateSession) challenge() (*edwards25519.Scalar, *edwards25519.Point, error) {
+	if len(s.randoms) != len(s.signers) {
+		return nil, nil, fmt.Errorf("session nonces not ready %d %d", len(s.randoms), len(s.signers))
+	}
+	P := edwards25519.NewIdentityPoint()
+	A := edwards25519.NewIdentityPoint()
+	for _, m := range s.signers {
+		p, err := edwards25519.NewIdentityPoint().SetBytes(s.randoms[m][:])
+		if err != nil {
+			return nil, nil, err
+		}
+		P = P.Add(P, p)
+		a, err := edwards25519.NewIdentityPoint().SetBytes(s.publics[m][:])
+		if err != nil {
+			return nil, nil, err
+		}
+		A = A.Add(A, a)
+	}
+	x, err := aggregateChallenge(P, A, s.message)
+	return x, P, err
+}
+
+func (s *AggregateSession) isSigner(m int) bool {
+	for _, i := range s.signers {
+		if i == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (signed *SignedTransaction) ValidateAggregateSigners(reader UTXOKeysReader) error {
+	as := signed.AggregatedSignature
+	if as == nil {
+		return fmt.Errorf("invalid aggregated signature %v", as)
+	}
+	for i, m := range as.Signers {
+		if m < 0 {
+			return fmt.Errorf("invalid aggregate signer index %d", m)
+		}
+		if i > 0 && m <= as.Signers[i-1] {
+			return fmt.Errorf("invalid aggregate signers order %d %d", as.Signers[i-1], m)
+		}
+	}
+
+	offset, si := 0, 0
+	for i, in := range signed.Inputs {
+		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
+		if err != nil {
+			return err
+		}
+		if utxo == nil {
+			return fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
+		}
+		signers, limit := 0, offset+len(utxo.Keys)
+		for ; si < len(as.Signers) && as.Signers[si] < limit; si++ {
+			signers += 1
+		}
+		err = utxo.Script.Validate(signers)
+		if err != nil {
+			return fmt.Errorf("invalid aggregate signers for input %d %v", i, err)
+		}
+		offset = limit
+	}
+	if si < len(as.Signers) {
+		return fmt.Errorf("invalid aggregate signer index %d/%d", as.Signers[si], offset)
+	}
+	return nil
+}
+
+// AggregateCoverage diagnoses the aggregate signers against the input thresholds,
+// missing are the absent key indices of the inputs below their thresholds, any
+// of them could complete the input, extra are the signers beyond a threshold,
+// duplicated or out of all inputs.
+func (signed *SignedTransaction) AggregateCoverage(reader UTXOKeysReader) (missing, extra []int, err error) {
+	as := signed.AggregatedSignature
+	if as == nil {
+		return nil, nil, fmt.Errorf("invalid aggregated signature %v", as)
+	}
+	present := make(map[int]bool)
+	for _, m := range as.Signers {
+		if present[m] {
+			extra = append(extra, m)
+		}
+		present[m] = true
+	}
+
+	offset := 0
+	for _, in := range signed.Inputs {
+		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
+		if err != nil {
+			return nil, nil, err
+		}
+		if utxo == nil {
+			return nil, nil, fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
+		}
+		err = utxo.Script.VerifyFormat()
+		if err != nil {
+			return nil, nil, err
+		}
+		var absent []int
+		signers, limit := 0, offset+len(utxo.Keys)
+		for m := offset; m < limit; m++ {
+			switch {
+			case !present[m]:
+				absent = append(absent, m)
+			case signers < int(utxo.Script[2]):
+				signers += 1
+			default:
+				extra = append(extra, m)
+			}
+			delete(present, m)
+		}
+		if signers < int(utxo.Script[2]) {
+			missing = append(missing, absent...)
+		}
+		offset = limit
+	}
+	for m := range present {
+		extra = append(extra, m)
+	}
+	slices.Sort(extra)
+	return missing, extra, nil
+}
+
+// SignerSetDiff returns the signers only in a and only in b, both sorted and
+// without duplicates.
+func SignerSetDiff(a, b []int) (onlyA, onlyB []int) {
+	as, bs := slices.Clone(a), slices.Clone(b)
+	slices.Sort(as)
+	slices.Sort(bs)
+	as, bs = slices.Compact(as), slices.Compact(bs)
+	for i, j := 0, 0; i < len(as) || j < len(bs); {
+		switch {
+		case j == len(bs) || i < len(as) && as[i] < bs[j]:
+			onlyA = append(onlyA, as[i])
+			i++
+		case i == len(as) || bs[j] < as[i]:
+			onlyB = append(onlyB, bs[j])
+			j++
+		default:
+			i, j = i+1, j+1
+		}
+	}
+	return onlyA, onlyB
+}
+
+// VerifyAggregatedSignature verifies the aggregated signature with the same
+// crypto.AggregateVerify of the kernel validation, the equation is checked
+// without cofactor, so an R with a small order component is always rejected.
+func (signed *SignedTransaction) VerifyAggregatedSignature(reader UTXOKeysReader) error {
+	err := signed.ValidateAggregateSigners(reader)
+	if err != nil {
+		return err
+	}
+	var publics []*crypto.Key
+	for _, in := range signed.Inputs {
+		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
+		if err != nil {
+			return err
+		}
+		publics = append(publics, utxo.Keys...)
+	}
+	as := signed.AggregatedSignature
+	return crypto.AggregateVerify(&as.Signature, publics, as.Signers, signed.AsVersioned().PayloadHash())
+}
+
+// AggregateMessageHash is the hash signed by AggregateSignMessage, the prefix
+// separates it from the encoded transactions, which all begin with the magic.
+func AggregateMessageHash(msg []byte) crypto.Hash {
+	return crypto.Blake3Hash(append([]byte("MIXIN:AGGREGATE:MESSAGE:"), msg...))
+}
+
+// AggregateSignMessage aggregates the signatures of keys over an arbitrary msg,
+// keys[i] is the private key of signers[i], the result is verified by
+// crypto.AggregateVerify with AggregateMessageHash(msg).
+func AggregateSignMessage(msg []byte, keys []*crypto.Key, signers []int, seed []byte) (*AggregatedSignature, error) {
+	if len(keys) == 0 || len(keys) != len(signers) {
+		return nil, fmt.Errorf("invalid aggregate keys %d signers %d", len(keys), len(signers))
+	}
+	for i, m := range signers {
+		if m < 0 {
+			return nil, fmt.Errorf("invalid aggregate signer index %d", m)
+		}
+		if i > 0 && m <= signers[i-1] {
+			return nil, fmt.Errorf("invalid aggregate signers order %d %d", signers[i-1], m)
+		}
+	}
+	return aggregateSign(context.Background(), AggregateMessageHash(msg), keys, slices.Clone(signers), seed)
+}
+
+// AggregateChallenge recomputes the challenge scalar x = H(R || A || m) of the
+// aggregated signature, A is the sum of the signer keys, m the payload hash.
+func (signed *SignedTransaction) AggregateChallenge(reader UTXOKeysReader) ([32]byte, error) {
+	var x [32]byte
+	as := signed.AggregatedSignature
+	if as == nil {
+		return x, fmt.Errorf("invalid aggregated signature %v", as)
+	}
+	var publics []*crypto.Key
+	for _, in := range signed.Inputs {
+		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
+		if err != nil {
+			return x, err
+		}
+		if utxo == nil {
+			return x, fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
+		}
+		publics = append(publics, utxo.Keys...)
+	}
+	A := edwards25519.NewIdentityPoint()
+	for _, m := range as.Signers {
+		if m < 0 || m >= len(publics) {
+			return x, fmt.Errorf("invalid aggregate signer index %d/%d", m, len(publics))
+		}
+		p, err := edwards25519.NewIdentityPoint().SetBytes(publics[m][:])
+		if err != nil {
+			return x, err
+		}
+		A = A.Add(A, p)
+	}
+	P, err := edwards25519.NewIdentityPoint().SetBytes(as.Signature[:32])
+	if err != nil {
+		return x, err
+	}
+	s, err := aggregateChallenge(P, A, signed.AsVersioned().PayloadHash())
+	if err != nil {
+		return x, err
+	}
+	copy(x[:], s.Bytes())
+	return x, nil
+}
+
+// SignerIndices returns the sorted and deduplicated global key indices of the
+// aggregated signers, the result is a copy and safe to modify.
+func (as *AggregatedSignature) SignerIndices() []int {
+	signers := slices.Clone(as.Signers)
+	slices.Sort(signers)
+	return slices.Compact(signers)
+}
+
+func (as *AggregatedSignature) Contains(index int) bool {
+	_, found := slices.BinarySearch(as.SignerIndices(), index)
+	return found
+}
@@ -0,0 +1,283 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"filippo.io/edwards25519"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateSession(t *testing.T) {
+	require := require.New(t)
+
+	accounts := make([]*Address, 0)
+	for i := 0; i < 16; i++ {
+		seed := make([]byte, 64)
+		seed[i] = byte(i)
+		a := NewAddressFromSeed(seed)
+		accounts = append(accounts, &a)
+	}
+	seed := make([]byte, 64)
+	crypto.ReadRand(seed)
+	store := storeImpl{seed: seed, accounts: accounts}
+
+	ver := NewTransactionV5(XINAssetId).AsVersioned()
+	ver.AddInput(crypto.Hash{}, 0)
+	ver.AddInput(crypto.Hash{}, 1)
+	ver.AddScriptOutput(accounts, NewThresholdScript(1), NewInteger(20000), seed)
+
+	aas := [][]*Address{accounts[0:1], accounts[0:2]}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := ver.AggregateSignContext(ctx, store, aas, seed)
+	require.ErrorIs(err, context.Canceled)
+	require.Nil(ver.AggregatedSignature)
+	err = ver.AggregateSign(store, aas, seed)
+	require.Nil(err)
+	expected := ver.AggregatedSignature
+	progress, err := ver.SignatureProgress(store)
+	require.Nil(err)
+	require.Equal([]InputProgress{{1, 1}, {2, 2}}, progress)
+	require.Equal([]int{0, 2, 3}, expected.Signers)
+	err = ver.AggregateSign(store, [][]*Address{accounts[0:1], {accounts[1], accounts[0]}}, seed)
+	require.Nil(err)
+	require.Equal(expected, ver.AggregatedSignature)
+	err = ver.AggregateSign(store, [][]*Address{accounts[0:1], {accounts[1], accounts[1]}}, seed)
+	require.NotNil(err)
+	require.Contains(err.Error(), "duplicate signer 3")
+	ver.AggregatedSignature = expected
+	require.Nil(ver.VerifyAggregatedSignature(store))
+	torsion, err := edwards25519.NewIdentityPoint().SetBytes(crypto.SmallOrderPoints()[4][:])
+	require.Nil(err)
+	R, err := edwards25519.NewIdentityPoint().SetBytes(expected.Signature[:32])
+	require.Nil(err)
+	malicious := *expected
+	copy(malicious.Signature[:32], R.Add(R, torsion).Bytes())
+	ver.AggregatedSignature = &malicious
+	require.NotNil(ver.VerifyAggregatedSignature(store))
+	ver.AggregatedSignature = expected
+	challenge, err := ver.AggregateChallenge(store)
+	require.Nil(err)
+	x, err := edwards25519.NewScalar().SetCanonicalBytes(challenge[:])
+	require.Nil(err)
+	S, err := edwards25519.NewScalar().SetCanonicalBytes(expected.Signature[32:])
+	require.Nil(err)
+	var publics []*crypto.Key
+	for _, in := range ver.Inputs {
+		utxo, err := store.ReadUTXOKeys(in.Hash, in.Index)
+		require.Nil(err)
+		publics = append(publics, utxo.Keys...)
+	}
+	A := edwards25519.NewIdentityPoint()
+	for _, m := range expected.Signers {
+		p, err := edwards25519.NewIdentityPoint().SetBytes(publics[m][:])
+		require.Nil(err)
+		A = A.Add(A, p)
+	}
+	R, err = edwards25519.NewIdentityPoint().SetBytes(expected.Signature[:32])
+	require.Nil(err)
+	sB := edwards25519.NewIdentityPoint().ScalarBaseMult(S)
+	xA := edwards25519.NewIdentityPoint().ScalarMult(x, A)
+	require.Equal(1, sB.Equal(R.Add(R, xA)))
+	ver.AggregatedSignature = nil
+	_, err = ver.AggregateChallenge(store)
+	require.NotNil(err)
+	ver.AggregatedSignature = expected
+	missing, extra, err := ver.AggregateCoverage(store)
+	require.Nil(err)
+	require.Len(missing, 0)
+	require.Len(extra, 0)
+	ver.AggregatedSignature = &AggregatedSignature{Signers: []int{0, 1, 2, 2, 7, 99}}
+	missing, extra, err = ver.AggregateCoverage(store)
+	require.Nil(err)
+	require.Equal([]int{3, 4}, missing)
+	require.Equal([]int{1, 2, 7, 99}, extra)
+	ver.AggregatedSignature = expected
+	complete, err := ver.IsComplete(store)
+	require.Nil(err)
+	require.True(complete)
+	ver.AggregatedSignature = &AggregatedSignature{Signers: []int{0, 2}}
+	complete, err = ver.IsComplete(store)
+	require.Nil(err)
+	require.False(complete)
+	ver.AggregatedSignature = nil
+
+	// signer global index => input index, account
+	members := map[int][2]int{0: {0, 0}, 2: {1, 0}, 3: {1, 1}}
+
+	_, err = ver.NewAggregateSession(store, []int{2, 0})
+	require.NotNil(err)
+	_, err = ver.NewAggregateSession(store, []int{0, 5})
+	require.NotNil(err)
+	session, err := ver.NewAggregateSession(store, expected.Signers)
+	require.Nil(err)
+
+	nonces := make(map[int]crypto.Key)
+	for _, m := range expected.Signers {
+		nonces[m] = AggregateNonce(seed, m)
+	}
+	err = session.Reveal(0, nonces[0].Public())
+	require.NotNil(err)
+	require.Contains(err.Error(), "commitments not ready")
+	for _, m := range expected.Signers {
+		err = session.Commit(m, AggregateNonceCommitment(nonces[m].Public()))
+		require.Nil(err)
+	}
+	require.NotNil(session.Commit(1, crypto.Hash{}))
+	require.NotNil(session.Commit(0, crypto.Hash{}))
+	require.NotNil(session.Reveal(2, nonces[0].Public()))
+	for _, m := range expected.Signers {
+		err = session.Reveal(m, nonces[m].Public())
+		require.Nil(err)
+	}
+
+	shares := make(map[int]*crypto.Key)
+	for _, m := range expected.Signers {
+		in := ver.Inputs[members[m][0]]
+		acc := accounts[members[m][1]]
+		utxo, err := store.ReadUTXOKeys(in.Hash, in.Index)
+		require.Nil(err)
+		priv := crypto.DeriveGhostPrivateKey(&utxo.Mask, &acc.PrivateViewKey, &acc.PrivateSpendKey, uint64(in.Index))
+		r := nonces[m]
+		share, err := session.Share(m, priv, &r)
+		require.Nil(err)
+		shares[m] = share
+	}
+	_, err = session.Combine(map[int]*crypto.Key{0: shares[0]})
+	require.NotNil(err)
+	bad := map[int]*crypto.Key{0: shares[0], 2: shares[3], 3: shares[2]}
+	_, err = session.Combine(bad)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid session share")
+
+	as, err := session.Combine(shares)
+	require.Nil(err)
+	require.Equal(expected.Signers, as.Signers)
+	require.Equal(expected.Signature, as.Signature)
+	ver.AggregatedSignature = as
+	err = ver.Validate(store, uint64(time.Now().UnixNano()), false)
+	require.Nil(err)
+	err = ver.ValidateAggregateSigners(store)
+	require.Nil(err)
+}
+
+func TestValidateAggregateSigners(t *testing.T) {
+	require := require.New(t)
+
+	accounts := make([]*Address, 0)
+	for i := 0; i < 4; i++ {
+		a := randomAccount()
+		accounts = append(accounts, &a)
+	}
+	seed := make([]byte, 64)
+	crypto.ReadRand(seed)
+	store := storeImpl{seed: seed, accounts: accounts}
+
+	ver := NewTransactionV5(XINAssetId).AsVersioned()
+	ver.AddInput(crypto.Hash{}, 0)
+	ver.AddInput(crypto.Hash{}, 1)
+	ver.AddScriptOutput(accounts, NewThresholdScript(1), NewInteger(20000), seed)
+
+	err := ver.ValidateAggregateSigners(store)
+	require.NotNil(err)
+
+	ver.AggregatedSignature = &AggregatedSignature{Signers: []int{0, 2, 3}}
+	require.Nil(ver.ValidateAggregateSigners(store))
+	ver.AggregatedSignature.Signers = []int{1, 3, 4}
+	require.Nil(ver.ValidateAggregateSigners(store))
+	ver.AggregatedSignature.Signers = []int{0, 3}
+	err = ver.ValidateAggregateSigners(store)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid aggregate signers for input 1")
+	ver.AggregatedSignature.Signers = []int{2, 3}
+	err = ver.ValidateAggregateSigners(store)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid aggregate signers for input 0")
+	ver.AggregatedSignature.Signers = []int{0, 3, 2}
+	err = ver.ValidateAggregateSigners(store)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid aggregate signers order")
+	ver.AggregatedSignature.Signers = []int{0, 2, 3, 5}
+	err = ver.ValidateAggregateSigners(store)
+	require.NotNil(err)
+	require.Equal("invalid aggregate signer index 5/5", err.Error())
+}
+
+func TestAggregatedSignatureSigners(t *testing.T) {
+	require := require.New(t)
+
+	as := &AggregatedSignature{Signers: []int{3, 0, 3, 2}}
+	require.Equal([]int{0, 2, 3}, as.SignerIndices())
+	require.Equal([]int{3, 0, 3, 2}, as.Signers)
+	require.True(as.Contains(0))
+	require.True(as.Contains(3))
+	require.False(as.Contains(1))
+	require.False(as.Contains(-1))
+
+	as = &AggregatedSignature{}
+	require.Len(as.SignerIndices(), 0)
+	require.False(as.Contains(0))
+
+	a, b := []int{5, 1, 3, 3, 9}, []int{3, 4, 9, 0, 4}
+	onlyA, onlyB := SignerSetDiff(a, b)
+	require.Equal([]int{1, 5}, onlyA)
+	require.Equal([]int{0, 4}, onlyB)
+	require.Equal([]int{5, 1, 3, 3, 9}, a)
+	onlyA, onlyB = SignerSetDiff(a, nil)
+	require.Equal([]int{1, 3, 5, 9}, onlyA)
+	require.Nil(onlyB)
+	onlyA, onlyB = SignerSetDiff(a, a)
+	require.Nil(onlyA)
+	require.Nil(onlyB)
+}
+
+func TestAggregateSignMessage(t *testing.T) {
+	require := require.New(t)
+
+	var publics, keys []*crypto.Key
+	for i := 0; i < 5; i++ {
+		a := randomAccount()
+		publics = append(publics, &a.PublicSpendKey)
+		if i%2 == 0 {
+			keys = append(keys, &a.PrivateSpendKey)
+		}
+	}
+	seed := make([]byte, 64)
+	crypto.ReadRand(seed)
+	msg := []byte("governance vote")
+
+	as, err := AggregateSignMessage(msg, keys, []int{0, 2, 4}, seed)
+	require.Nil(err)
+	require.Equal([]int{0, 2, 4}, as.Signers)
+	require.Nil(crypto.AggregateVerify(&as.Signature, publics, as.Signers, AggregateMessageHash(msg)))
+	require.NotNil(crypto.AggregateVerify(&as.Signature, publics, as.Signers, crypto.Blake3Hash(msg)))
+	require.NotNil(crypto.AggregateVerify(&as.Signature, publics, []int{0, 2, 3}, AggregateMessageHash(msg)))
+
+	nonce := AggregateNonce(seed, 2)
+	var hashed [][]byte
+	crypto.NonceHash = func(b []byte) crypto.Hash {
+		hashed = append(hashed, b)
+		return crypto.Sha256Hash(b)
+	}
+	defer func() { crypto.NonceHash = crypto.Blake3Hash }()
+	sh := crypto.Sha256Hash(append(append([]byte{}, seed...), 0, 2))
+	require.Equal(crypto.NewKeyFromSeed(append(sh[:], sh[:]...)), AggregateNonce(seed, 2))
+	require.NotEqual(nonce, AggregateNonce(seed, 2))
+	hashed = nil
+	stub, err := AggregateSignMessage(msg, keys, []int{0, 2, 4}, seed)
+	require.Nil(err)
+	require.Len(hashed, 3)
+	require.NotEqual(as.Signature, stub.Signature)
+	require.Nil(crypto.AggregateVerify(&stub.Signature, publics, stub.Signers, AggregateMessageHash(msg)))
+	crypto.NonceHash = crypto.Blake3Hash
+	require.Equal(nonce, AggregateNonce(seed, 2))
+
+	_, err = AggregateSignMessage(msg, keys, []int{0, 2}, seed)
+	require.NotNil(err)
+	_, err = AggregateSignMessage(msg, keys, []int{0, 4, 2}, seed)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid aggregate signers order")
+}
@@ -69,6 +69,6 @@ func GetAssetCapacity(id crypto.Hash) Integer {
 	case DOGEAssetId:
 		return NewIntegerFromString("25000000")
 	default: // TODO more assets and better default value
-		return NewIntegerFromString("115792089237316195423570985008687907853269984665640564039457.58400791")
+		return MaximumSupply
 	}
 }
@@ -0,0 +1,64 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/MixinNetwork/mixin/crypto"
+)
+
+// TxBuilder caches the encoding of each input and output when added, so the
+// preview hash doesn't encode them again. The counts are encoded before the
+// inputs and outputs, so the hash itself has to be recomputed over the cached
+// encodings each time, and the inputs and outputs must not be changed after.
+type TxBuilder struct {
+	tx      *Transaction
+	inputs  [][]byte
+	outputs [][]byte
+}
+
+func NewTxBuilder(asset crypto.Hash) *TxBuilder {
+	return &TxBuilder{tx: NewTransactionV5(asset)}
+}
+
+func (b *TxBuilder) AddInput(hash crypto.Hash, index uint) {
+	b.tx.AddInput(hash, index)
+	enc := NewEncoder()
+	enc.EncodeInput(b.tx.Inputs[len(b.tx.Inputs)-1])
+	b.inputs = append(b.inputs, enc.Bytes())
+}
+
+func (b *TxBuilder) AddOutput(o *Output) {
+	b.tx.Outputs = append(b.tx.Outputs, o)
+	enc := NewEncoder()
+	enc.EncodeOutput(o)
+	b.outputs = append(b.outputs, enc.Bytes())
+}
+
+func (b *TxBuilder) AddScriptOutput(accounts []*Address, s Script, amount Integer, seed []byte) {
+	b.tx.AddScriptOutput(accounts, s, amount, seed)
+	enc := NewEncoder()
+	enc.EncodeOutput(b.tx.Outputs[len(b.tx.Outputs)-1])
+	b.outputs = append(b.outputs, enc.Bytes())
+}
+
+// Transaction returns the transaction being built, its references and extra
+// can be changed at any time, they are always encoded by CurrentHash.
+func (b *TxBuilder) Transaction() *Transaction {
+	return b.tx
+}
+
+// CurrentHash is the PayloadHash of the transaction built so far.
+func (b *TxBuilder) CurrentHash() (crypto.Hash, error) {
+	tx := b.tx
+	if len(tx.Extra) > ExtraSizeStorageCapacity {
+		return crypto.Hash{}, fmt.Errorf("invalid extra size %d", len(tx.Extra))
+	}
+	val := encodePooled(func(enc *Encoder) []byte {
+		input := func(i int) { enc.Write(b.inputs[i]) }
+		output := func(i int) { enc.Write(b.outputs[i]) }
+		enc.writeTransactionPayload(tx, input, output, func(string, int) {})
+		enc.WriteInt(0)
+		return enc.Bytes()
+	})
+	return crypto.Blake3Hash(val), nil
+}
@@ -144,6 +144,43 @@ func ParseCustodianUpdateNodesExtra(extra []byte, genesis bool) (*CustodianUpdat
 	}, nil
 }
 
+// NewCustodianUpdateTransaction builds the custodian update of the nodes to
+// the custodian, approved by the private spend key of the current custodian.
+// The inputs to pay the amount are left to the caller.
+func NewCustodianUpdateTransaction(custodian *Address, nodes []*CustodianNode, approver *crypto.Key, amount Integer, seed []byte) (*Transaction, error) {
+	if len(nodes) < custodianNodesMinimumCount {
+		return nil, fmt.Errorf("invalid custodian nodes count %d", len(nodes))
+	}
+	sorted := make([]*CustodianNode, len(nodes))
+	copy(sorted, nodes)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Custodian.PublicSpendKey[:], sorted[j].Custodian.PublicSpendKey[:]) < 0
+	})
+
+	extra := append(custodian.PublicSpendKey[:], custodian.PublicViewKey[:]...)
+	for _, n := range sorted {
+		cn, err := parseCustodianNode(n.Extra, false)
+		if err != nil {
+			return nil, err
+		}
+		if cn.Custodian.String() != n.Custodian.String() || cn.Payee.String() != n.Payee.String() {
+			return nil, fmt.Errorf("invalid custodian node extra %x", n.Extra)
+		}
+		extra = append(extra, n.Extra...)
+	}
+	sig := approver.Sign(crypto.Blake3Hash(extra))
+	extra = append(extra, sig[:]...)
+	_, err := ParseCustodianUpdateNodesExtra(extra, false)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := NewTransactionV5(XINAssetId)
+	tx.AddOutputWithType(OutputTypeCustodianUpdateNodes, []*Address{custodian}, NewThresholdScript(Operator64), amount, seed)
+	tx.Extra = extra
+	return tx, nil
+}
+
 func (tx *Transaction) validateCustodianUpdateNodes(store CustodianReader, now uint64) error {
 	if tx.Version < TxVersionHashSignature {
 		return fmt.Errorf("invalid custodian update version %d", tx.Version)
@@ -214,6 +251,9 @@ func (tx *Transaction) validateCustodianUpdateNodes(store CustodianReader, now u
 	return nil
 }
 
+// The custodian slash nodes transaction has no data format yet, the output
+// carries no node ids and every slash transaction is rejected, so there is
+// nothing to parse until the format is specified.
 func (tx *Transaction) validateCustodianSlashNodes(_ DataStore) error {
 	return fmt.Errorf("not implemented %v", tx)
 }
@@ -118,6 +118,53 @@ func TestCustodianUpdateNodes(t *testing.T) {
 	require.Nil(err)
 }
 
+func TestNewCustodianUpdateTransaction(t *testing.T) {
+	require := require.New(t)
+
+	mainnet, _ := crypto.HashFromString(mainnetId)
+	domain := testBuildAddress(require)
+	custodian := testBuildAddress(require)
+	store := &testCustodianStore{domain: &domain}
+
+	count := custodianNodesMinimumCount
+	nodes := make([]*CustodianNode, count)
+	for i := 0; i < count; i++ {
+		signer := testBuildAddress(require)
+		payee := testBuildAddress(require)
+		custodian := testBuildAddress(require)
+		extra := EncodeCustodianNode(&custodian, &payee, &signer.PrivateSpendKey, &payee.PrivateSpendKey, &custodian.PrivateSpendKey, mainnet)
+		nodes[i] = &CustodianNode{custodian, payee, extra}
+	}
+
+	amount := NewInteger(100).Mul(count)
+	_, err := NewCustodianUpdateTransaction(&custodian, nodes[1:], &domain.PrivateSpendKey, amount, make([]byte, 64))
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid custodian nodes count")
+
+	payee := nodes[0].Payee
+	nodes[0].Payee = nodes[1].Payee
+	_, err = NewCustodianUpdateTransaction(&custodian, nodes, &domain.PrivateSpendKey, amount, make([]byte, 64))
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid custodian node extra")
+	nodes[0].Payee = payee
+
+	tx, err := NewCustodianUpdateTransaction(&custodian, nodes, &domain.PrivateSpendKey, amount, make([]byte, 64))
+	require.Nil(err)
+	require.Equal(uint8(TransactionTypeCustodianUpdateNodes), tx.AsVersioned().TransactionType())
+	err = tx.validateCustodianUpdateNodes(store, uint64(time.Now().UnixNano()))
+	require.Nil(err)
+	curs, err := ParseCustodianUpdateNodesExtra(tx.Extra, false)
+	require.Nil(err)
+	require.Equal(custodian.String(), curs.Custodian.String())
+	require.Len(curs.Nodes, count)
+
+	tx, err = NewCustodianUpdateTransaction(&custodian, nodes, &custodian.PrivateSpendKey, amount, make([]byte, 64))
+	require.Nil(err)
+	err = tx.validateCustodianUpdateNodes(store, uint64(time.Now().UnixNano()))
+	require.NotNil(err)
+	require.Contains(err.Error(), "approval signature")
+}
+
 func TestCustodianParseNode(t *testing.T) {
 	require := require.New(t)
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/MixinNetwork/mixin/config"
 	"github.com/MixinNetwork/mixin/crypto"
 )
 
@@ -99,6 +100,20 @@ func (dec *Decoder) DecodeSnapshotWithTopo() (*SnapshotWithTopologicalOrder, err
 	return topo, nil
 }
 
+// SafeDecodeSignedTransaction decodes the transaction from untrusted bytes,
+// and any panic during the decoding is returned as an error.
+func SafeDecodeSignedTransaction(b []byte) (tx *SignedTransaction, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tx, err = nil, fmt.Errorf("malformed transaction %v", r)
+		}
+	}()
+	if len(b) > config.TransactionMaximumSize {
+		return nil, fmt.Errorf("transaction too large %d", len(b))
+	}
+	return NewDecoder(b).DecodeTransaction()
+}
+
 func (dec *Decoder) DecodeTransaction() (*SignedTransaction, error) {
 	b := make([]byte, 4)
 	err := dec.Read(b)
@@ -22,6 +22,64 @@ func (d *DepositData) Asset() *Asset {
 	}
 }
 
+// ExternalHash is the transaction hash of the deposit on the source chain.
+func (d *DepositData) ExternalHash() string {
+	return d.Transaction
+}
+
+// Bytes encodes the deposit data the same as the deposit input of a transaction.
+func (d *DepositData) Bytes() []byte {
+	enc := NewMinimumEncoder()
+	enc.Write(d.Chain[:])
+	enc.WriteInt(len(d.AssetKey))
+	enc.Write([]byte(d.AssetKey))
+	enc.WriteInt(len(d.Transaction))
+	enc.Write([]byte(d.Transaction))
+	enc.WriteUint64(d.Index)
+	enc.WriteInteger(d.Amount)
+	return enc.Bytes()
+}
+
+func ParseDepositData(b []byte) (*DepositData, error) {
+	dec, err := NewMinimumDecoder(b)
+	if err != nil {
+		return nil, err
+	}
+	d := &DepositData{}
+	err = dec.Read(d.Chain[:])
+	if err != nil {
+		return nil, err
+	}
+	ak, err := dec.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	d.AssetKey = string(ak)
+	th, err := dec.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	d.Transaction = string(th)
+	d.Index, err = dec.ReadUint64()
+	if err != nil {
+		return nil, err
+	}
+	d.Amount, err = dec.ReadInteger()
+	if err != nil {
+		return nil, err
+	}
+	if dec.buf.Len() != 0 {
+		return nil, fmt.Errorf("invalid deposit data size %d", len(b))
+	}
+	if err := d.Asset().Verify(); err != nil {
+		return nil, fmt.Errorf("invalid asset data %s", err.Error())
+	}
+	if strings.TrimSpace(d.Transaction) != d.Transaction || len(d.Transaction) == 0 {
+		return nil, fmt.Errorf("invalid transaction hash %s", d.Transaction)
+	}
+	return d, nil
+}
+
 func (d *DepositData) UniqueKey() crypto.Hash {
 	index := fmt.Sprintf("%s:%s:%d", d.Chain, d.Transaction, d.Index)
 	return crypto.Sha256Hash([]byte(index)).ForNetwork(d.Chain)
@@ -3,7 +3,9 @@ package common
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/MixinNetwork/mixin/crypto"
 )
@@ -30,6 +32,25 @@ func NewEncoder() *Encoder {
 	return &Encoder{buf: new(bytes.Buffer)}
 }
 
+// the pooled buffers larger than this are dropped, not to hold huge extras
+const encoderPoolBufferLimit = 1024 * 1024
+
+var encoderPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// encodePooled encodes with a pooled buffer to avoid growing a new one for
+// each large transaction, the result is copied out before the buffer reuse.
+func encodePooled(encode func(enc *Encoder) []byte) []byte {
+	buf := encoderPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	b := bytes.Clone(encode(&Encoder{buf: buf}))
+	if buf.Cap() <= encoderPoolBufferLimit {
+		encoderPool.Put(buf)
+	}
+	return b
+}
+
 func NewMinimumEncoder() *Encoder {
 	enc := NewEncoder()
 	enc.Write(magic)
@@ -83,39 +104,53 @@ func (enc *Encoder) encodeSnapshotPayload(s *Snapshot, withSig bool) {
 }
 
 func (enc *Encoder) EncodeTransaction(signed *SignedTransaction) []byte {
+	return enc.encodeTransaction(signed, nil)
+}
+
+// EncodeWithLayout encodes the transaction the same as Marshal, and returns the
+// [start, end) byte range of each field, the inputs and outputs are keyed by
+// their index, e.g. inputs[0], and the ranges of the slices include the count.
+func (signed *SignedTransaction) EncodeWithLayout() (b []byte, layout map[string][2]int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			b, layout, err = nil, nil, fmt.Errorf("malformed transaction %v", r)
+		}
+	}()
 	if signed.Version < TxVersionHashSignature {
-		panic(signed)
+		return nil, nil, fmt.Errorf("invalid tx version %d", signed.Version)
 	}
+	layout = make(map[string][2]int)
+	b = NewEncoder().encodeTransaction(signed, layout)
+	return b, layout, nil
+}
 
-	enc.Write(magic)
-	enc.Write([]byte{0x00, signed.Version})
-	enc.Write(signed.Asset[:])
-
-	il := len(signed.Inputs)
-	enc.WriteInt(il)
-	for _, in := range signed.Inputs {
-		enc.EncodeInput(in)
+func (enc *Encoder) encodeTransaction(signed *SignedTransaction, layout map[string][2]int) []byte {
+	if signed.Version < TxVersionHashSignature {
+		panic(signed)
 	}
-
-	ol := len(signed.Outputs)
-	enc.WriteInt(ol)
-	for _, out := range signed.Outputs {
-		enc.EncodeOutput(out)
+	mark := func(name string, start int) {
+		if layout != nil {
+			layout[name] = [2]int{start, enc.buf.Len()}
+		}
 	}
 
-	rl := len(signed.References)
-	enc.WriteInt(rl)
-	for _, r := range signed.References {
-		enc.Write(r[:])
+	input := func(i int) {
+		from := enc.buf.Len()
+		enc.EncodeInput(signed.Inputs[i])
+		if layout != nil {
+			mark(fmt.Sprintf("inputs[%d]", i), from)
+		}
 	}
-
-	el := len(signed.Extra)
-	if el > ExtraSizeStorageCapacity {
-		panic(el)
+	output := func(i int) {
+		from := enc.buf.Len()
+		enc.EncodeOutput(signed.Outputs[i])
+		if layout != nil {
+			mark(fmt.Sprintf("outputs[%d]", i), from)
+		}
 	}
-	enc.WriteUint32(uint32(el))
-	enc.Write(signed.Extra)
+	enc.writeTransactionPayload(&signed.Transaction, input, output, mark)
 
+	start := enc.buf.Len()
 	if signed.AggregatedSignature != nil {
 		enc.EncodeAggregatedSignature(signed.AggregatedSignature)
 	} else {
@@ -128,10 +163,57 @@ func (enc *Encoder) EncodeTransaction(signed *SignedTransaction) []byte {
 			enc.EncodeSignatures(sm)
 		}
 	}
+	mark("signatures", start)
 
 	return enc.Bytes()
 }
 
+// writeTransactionPayload writes the transaction fields before the signatures,
+// each input and output is written by the callback with its index after the
+// count, so the encodings cached by TxBuilder can be written directly.
+func (enc *Encoder) writeTransactionPayload(tx *Transaction, input, output func(i int), mark func(name string, start int)) {
+	start := enc.buf.Len()
+	enc.Write(magic)
+	enc.Write([]byte{0x00, tx.Version})
+	mark("version", start)
+	start = enc.buf.Len()
+	enc.Write(tx.Asset[:])
+	mark("asset", start)
+
+	start = enc.buf.Len()
+	il := len(tx.Inputs)
+	enc.WriteInt(il)
+	for i := 0; i < il; i++ {
+		input(i)
+	}
+	mark("inputs", start)
+
+	start = enc.buf.Len()
+	ol := len(tx.Outputs)
+	enc.WriteInt(ol)
+	for i := 0; i < ol; i++ {
+		output(i)
+	}
+	mark("outputs", start)
+
+	start = enc.buf.Len()
+	rl := len(tx.References)
+	enc.WriteInt(rl)
+	for _, r := range tx.References {
+		enc.Write(r[:])
+	}
+	mark("references", start)
+
+	start = enc.buf.Len()
+	el := len(tx.Extra)
+	if el > ExtraSizeStorageCapacity {
+		panic(el)
+	}
+	enc.WriteUint32(uint32(el))
+	enc.Write(tx.Extra)
+	mark("extra", start)
+}
+
 func (enc *Encoder) EncodeInput(in *Input) {
 	if in.Index > 1024 {
 		panic(in.Index)
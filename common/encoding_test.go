@@ -1,7 +1,11 @@
 package common
 
 import (
+	"bytes"
 	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/MixinNetwork/mixin/crypto"
@@ -50,6 +54,19 @@ func TestEncoding(t *testing.T) {
 	require.NotNil(w)
 	require.Equal("4KE9w4tneAtr2BWsmhwi6EbB1CbWqgyBBH2oCg9vwgn94nZZMcyiLvU4zYkbwp2wuNJYVQUkwyZFfN8Frb84QxUgpg1tenWLadx4UERX2pH", w.Address)
 	require.Equal("", w.Tag)
+	wb := w.Bytes()
+	require.Equal("77770001006b344b45397734746e65417472324257736d6877693645624231436257716779424248326f4367397677676e39346e5a5a4d6379694c7655347a596b6277703277754e4a595651556b77795a46664e3846726238345178556770673174656e574c61647834554552583270480000", hex.EncodeToString(wb))
+	pw, err := ParseWithdrawalData(wb)
+	require.Nil(err)
+	require.Equal(w, pw)
+	_, err = ParseWithdrawalData(append(wb, 0))
+	require.NotNil(err)
+	_, err = ParseWithdrawalData(wb[:len(wb)-1])
+	require.NotNil(err)
+	tw := &WithdrawalData{Address: "0xMIXINTODAMOON", Tag: "21BTC"}
+	pw, err = ParseWithdrawalData(tw.Bytes())
+	require.Nil(err)
+	require.Equal(tw, pw)
 
 	enc := hex.EncodeToString(NewEncoder().EncodeTransaction(signed))
 	require.Equal(raw, enc)
@@ -57,11 +74,98 @@ func TestEncoding(t *testing.T) {
 	enc = hex.EncodeToString(signed.AsVersioned().PayloadMarshal())
 	require.Equal(raw, enc)
 
+	lb, layout, err := signed.EncodeWithLayout()
+	require.Nil(err)
+	require.Equal(raw, hex.EncodeToString(lb))
+	require.Equal([2]int{0, 4}, layout["version"])
+	require.Equal(signed.Asset[:], lb[layout["asset"][0]:layout["asset"][1]])
+	offset := layout["inputs"][0] + 2
+	for i := range signed.Inputs {
+		f := fmt.Sprintf("inputs[%d]", i)
+		require.Equal(offset, layout[f][0], f)
+		offset = layout[f][1]
+	}
+	require.Equal(layout["inputs"][1], offset)
+	offset = 0
+	for _, f := range []string{"version", "asset", "inputs", "outputs", "references", "extra", "signatures"} {
+		require.Equal(offset, layout[f][0], f)
+		offset = layout[f][1]
+	}
+	require.Equal(len(lb), offset)
+	require.Len(layout, 7+len(signed.Inputs)+len(signed.Outputs))
+	require.Equal(signed.Extra, lb[layout["extra"][0]+4:layout["extra"][1]])
+	signed.Version = 4
+	_, _, err = signed.EncodeWithLayout()
+	require.NotNil(err)
+	signed.Version = TxVersionHashSignature
+
 	raw = "77770005a99c2e0e2b1da4d648755ef19bd95139acbbe6564cfb06dec7cd34931ca72cdc0001c19d51beba90c20ff538a32ab262ce6e32e59f03b5bfe6d8e6fe2b2544ba43b60000000000000000000100a40005e8d4a510000000000000000000000000000000000000000000000000000000000000000000000000000000000000000040cf0926f381bb17668ef4b4eab6243d4b437ae6d2372623b74f41a5597277495556515cbc346d8b639386c1e22239d032bb6f09f8b6f2ea5a3a19b41fe0bdd1de0000"
 	val, _ = hex.DecodeString(raw)
 	signed, err = NewDecoder(val).DecodeTransaction()
 	require.Nil(err)
 	require.Equal("cf0926f381bb17668ef4b4eab6243d4b437ae6d2372623b74f41a5597277495556515cbc346d8b639386c1e22239d032bb6f09f8b6f2ea5a3a19b41fe0bdd1de", hex.EncodeToString(signed.Extra))
+	require.True(signed.IsCanonical(val))
+	require.False(signed.IsCanonical(val[:len(val)-1]))
+	require.False(signed.IsCanonical(append(val, 0)))
+
+	padded, _ := hex.DecodeString(strings.Replace(raw, "0005e8d4a51000", "000600e8d4a51000", 1))
+	other, err := NewDecoder(padded).DecodeTransaction()
+	require.Nil(err)
+	require.Equal(signed.Outputs[0].Amount, other.Outputs[0].Amount)
+	require.False(signed.IsCanonical(padded))
+	require.False(other.IsCanonical(padded))
+	require.True(other.IsCanonical(val))
+
+	u := signed.EncodeURL()
+	require.NotContains(u, "=")
+	require.NotContains(u, "+")
+	require.NotContains(u, "/")
+	decoded, err := DecodeSignedTransactionURL(u)
+	require.Nil(err)
+	require.Equal(raw, hex.EncodeToString(decoded.AsVersioned().Marshal()))
+	_, err = DecodeSignedTransactionURL(u + "=")
+	require.NotNil(err)
+	_, err = DecodeSignedTransactionURL(u[:len(u)-8])
+	require.NotNil(err)
+
+	var records bytes.Buffer
+	n, err := signed.EncodeTo(&records)
+	require.Nil(err)
+	require.Equal(len(val)+4, n)
+	n, err = other.EncodeTo(&records)
+	require.Nil(err)
+	require.Equal(len(val)+4, n)
+	require.Equal(2*n, records.Len())
+	whole := records.Bytes()
+	for range 2 {
+		decoded, err = DecodeSignedTransactionFrom(&records)
+		require.Nil(err)
+		require.Equal(raw, hex.EncodeToString(decoded.AsVersioned().Marshal()))
+	}
+	_, err = DecodeSignedTransactionFrom(&records)
+	require.ErrorIs(err, io.EOF)
+	_, err = DecodeSignedTransactionFrom(bytes.NewReader(whole[:n-1]))
+	require.ErrorIs(err, io.ErrUnexpectedEOF)
+	_, err = DecodeSignedTransactionFrom(bytes.NewReader(whole[:4]))
+	require.ErrorIs(err, io.ErrUnexpectedEOF)
+}
+
+func FuzzSafeDecodeSignedTransaction(f *testing.F) {
+	raw := "77770005a99c2e0e2b1da4d648755ef19bd95139acbbe6564cfb06dec7cd34931ca72cdc0001c19d51beba90c20ff538a32ab262ce6e32e59f03b5bfe6d8e6fe2b2544ba43b60000000000000000000100a40005e8d4a510000000000000000000000000000000000000000000000000000000000000000000000000000000000000000040cf0926f381bb17668ef4b4eab6243d4b437ae6d2372623b74f41a5597277495556515cbc346d8b639386c1e22239d032bb6f09f8b6f2ea5a3a19b41fe0bdd1de0000"
+	val, _ := hex.DecodeString(raw)
+	f.Add(val)
+	f.Add(val[:len(val)/2])
+	f.Add([]byte{})
+	f.Add([]byte{0x77, 0x77, 0x00, 0x05})
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		tx, err := SafeDecodeSignedTransaction(b)
+		if err != nil {
+			require.Nil(t, tx)
+		} else {
+			require.NotNil(t, tx)
+		}
+	})
 }
 
 func TestAggregatedSignatureEncoding(t *testing.T) {
@@ -135,4 +239,42 @@ func TestCommonDataEncoding(t *testing.T) {
 	require.Equal(uint64(123), res.Batch)
 	require.Equal("3.14159000", res.Amount.String())
 	require.Equal("eea889c227076f8c62106b59a478e043c0030392f3be0f5d714ed27953cb2668", res.Transaction.String())
+
+	mb := mint.MintData.Bytes()
+	md, err := ParseMintData(mb)
+	require.Nil(err)
+	require.Equal(mint.MintData, *md)
+	_, err = ParseMintData(append(mb, 0))
+	require.NotNil(err)
+	_, err = ParseMintData(mb[:len(mb)-1])
+	require.NotNil(err)
+	_, err = ParseMintData((&MintData{Group: "KERNELNODE", Amount: NewInteger(1)}).Bytes())
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid mint group")
+
+	chain, _ := crypto.HashFromString("8dd50817c082cdcdd6f167514928767a4b52426997bd6d4930eca101c5ff8a27")
+	deposit := &DepositData{
+		Chain:       chain,
+		AssetKey:    "0xa974c709cfb4566686553a20790685a47aceaa33",
+		Transaction: "0x426ce53523b2f24d0f20707ef169f9cc5a1eea34287210873421bd1e5e5d2718",
+		Index:       7,
+		Amount:      NewIntegerFromString("1006"),
+	}
+	require.Equal(deposit.Transaction, deposit.ExternalHash())
+	db := deposit.Bytes()
+	dd, err := ParseDepositData(db)
+	require.Nil(err)
+	require.Equal(deposit, dd)
+	require.Equal(chain, dd.Chain)
+	tx := NewTransactionV5(XINAssetId)
+	tx.AddDepositInput(deposit)
+	require.Contains(hex.EncodeToString(tx.AsVersioned().Marshal()), hex.EncodeToString(db[4:]))
+	_, err = ParseDepositData(append(db, 0))
+	require.NotNil(err)
+	_, err = ParseDepositData(db[:len(db)-1])
+	require.NotNil(err)
+	deposit.Transaction = " " + deposit.Transaction
+	_, err = ParseDepositData(deposit.Bytes())
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid transaction hash")
 }
@@ -83,4 +83,16 @@ func TestTransactionExtraLimit(t *testing.T) {
 	require.Len(ver.AggregatedSignature.Signers, 3)
 	err = ver.Validate(store, uint64(time.Now().UnixNano()), false)
 	require.Nil(err)
+	fee, err := ver.ImpliedFee(store)
+	require.Nil(err)
+	require.Equal("40.96900000", fee.String())
+
+	ver.Outputs[0].Script = NewThresholdScript(63)
+	fee, err = ver.ImpliedFee(store)
+	require.Nil(err)
+	require.Equal(0, fee.Sign())
+	ver.Outputs[1].Amount = NewIntegerFromString("20000")
+	_, err = ver.ImpliedFee(store)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid input output amount")
 }
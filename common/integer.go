@@ -12,7 +12,12 @@ import (
 
 const Precision = 8
 
-var Zero = NewInteger(0)
+var (
+	Zero = NewInteger(0)
+
+	// MaximumSupply is the cap of any asset amount in the protocol
+	MaximumSupply = NewIntegerFromString("115792089237316195423570985008687907853269984665640564039457.58400791")
+)
 
 type Integer struct {
 	i big.Int
@@ -50,6 +55,19 @@ func (x Integer) Add(y Integer) (v Integer) {
 	return
 }
 
+// AddChecked is Add without panics, and errors if the sum exceeds MaximumSupply.
+func (x Integer) AddChecked(y Integer) (Integer, error) {
+	if x.Sign() < 0 || y.Sign() <= 0 {
+		return Zero, fmt.Errorf("invalid integer addition %s %s", x, y)
+	}
+	var v Integer
+	v.i.Add(&x.i, &y.i)
+	if v.Cmp(MaximumSupply) > 0 {
+		return Zero, fmt.Errorf("integer addition overflow %s %s", x, y)
+	}
+	return v, nil
+}
+
 func (x Integer) Sub(y Integer) (v Integer) {
 	if x.Sign() < 0 || y.Sign() <= 0 {
 		panic(fmt.Sprint(x, y))
@@ -91,6 +109,28 @@ func (x Integer) Count(y Integer) uint64 {
 	return c.Uint64()
 }
 
+// DivMod returns the whole quotient q and the remainder r, x = q * y + r.
+func (x Integer) DivMod(y Integer) (q, r Integer, err error) {
+	if y.Sign() <= 0 {
+		return Zero, Zero, fmt.Errorf("invalid integer division %s %s", x, y)
+	}
+	if x.Sign() < 0 {
+		return Zero, Zero, fmt.Errorf("invalid integer division %s %s", x, y)
+	}
+	c := new(big.Int)
+	c.DivMod(&x.i, &y.i, &r.i)
+	q.i.Mul(c, big.NewInt(int64(math.Pow(10, Precision))))
+	return q, r, nil
+}
+
+// Delta returns the absolute difference of x and y, and the sign of x - y.
+func (x Integer) Delta(y Integer) (abs Integer, sign int) {
+	abs.i.Sub(&x.i, &y.i)
+	sign = abs.i.Sign()
+	abs.i.Abs(&abs.i)
+	return abs, sign
+}
+
 func (x Integer) Cmp(y Integer) int {
 	return x.i.Cmp(&y.i)
 }
@@ -108,6 +148,29 @@ func (x Integer) String() string {
 	return "0." + strings.Repeat("0", -p) + s
 }
 
+// Format renders x for display only, truncated to decimals digits at most
+// Precision, with the integer part grouped by commas if separator.
+func (x Integer) Format(decimals int, separator bool) string {
+	decimals = max(0, min(decimals, Precision))
+	s := x.String()
+	p := strings.IndexByte(s, '.')
+	whole, frac := s[:p], s[p+1:p+1+decimals]
+	if separator {
+		var b strings.Builder
+		for i := range len(whole) {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				b.WriteByte(',')
+			}
+			b.WriteByte(whole[i])
+		}
+		whole = b.String()
+	}
+	if decimals == 0 {
+		return whole
+	}
+	return whole + "." + frac
+}
+
 func (x Integer) MarshalJSON() ([]byte, error) {
 	s := x.String()
 	return []byte(strconv.Quote(s)), nil
@@ -28,6 +28,57 @@ func TestInteger(t *testing.T) {
 	require.Equal(0, c.Sub(a).Cmp(b))
 	require.Equal(0, c.Sub(b).Cmp(a))
 
+	q, r, err := NewInteger(10).DivMod(NewInteger(3))
+	require.Nil(err)
+	require.Equal("3.00000000", q.String())
+	require.Equal("1.00000000", r.String())
+	q, r, err = NewIntegerFromString("500000").DivMod(NewIntegerFromString("0.00000007"))
+	require.Nil(err)
+	require.Equal("7142857142857.00000000", q.String())
+	require.Equal("0.00000001", r.String())
+	q, r, err = NewInteger(2).DivMod(NewInteger(3))
+	require.Nil(err)
+	require.Equal(0, q.Sign())
+	require.Equal("2.00000000", r.String())
+	_, _, err = a.DivMod(Zero)
+	require.NotNil(err)
+
+	delta, sign := NewIntegerFromString("1.5").Delta(NewInteger(2))
+	require.Equal("0.50000000", delta.String())
+	require.Equal(-1, sign)
+	delta, sign = NewInteger(2).Delta(NewIntegerFromString("1.5"))
+	require.Equal("0.50000000", delta.String())
+	require.Equal(1, sign)
+	delta, sign = NewInteger(2).Delta(NewInteger(2))
+	require.Equal(0, delta.Sign())
+	require.Equal(0, sign)
+	delta, sign = Zero.Delta(NewInteger(3))
+	require.Equal("3.00000000", delta.String())
+	require.Equal(-1, sign)
+
+	f := NewIntegerFromString("1234567.12345678")
+	require.Equal("1,234,567.12", f.Format(2, true))
+	require.Equal("1234567.1234", f.Format(4, false))
+	require.Equal("1,234,567", f.Format(0, true))
+	require.Equal("1,234,567.12345678", f.Format(12, true))
+	require.Equal("1234567", f.Format(-1, false))
+	require.Equal("123,456.00", NewInteger(123456).Format(2, true))
+	require.Equal("0.0000", NewIntegerFromString("0.00001").Format(4, true))
+	require.Equal("1,000", NewInteger(1000).Format(0, true))
+	require.Equal("999", NewInteger(999).Format(0, true))
+
+	d, err := a.AddChecked(b)
+	require.Nil(err)
+	require.Equal(0, d.Cmp(c))
+	_, err = a.AddChecked(Zero)
+	require.NotNil(err)
+	d, err = MaximumSupply.Sub(a).AddChecked(a)
+	require.Nil(err)
+	require.Equal(0, d.Cmp(MaximumSupply))
+	_, err = MaximumSupply.AddChecked(NewIntegerFromString("0.00000001"))
+	require.NotNil(err)
+	require.Contains(err.Error(), "integer addition overflow")
+
 	a = NewIntegerFromString("0.000000001")
 	require.Equal("0.00000000", a.String())
 	a = NewIntegerFromString("10.000000001")
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/MixinNetwork/mixin/config"
+)
+
+// TransactionLimits are the count limits checked by the transaction
+// validation, the defaults are the kernel rules, and only testnets should
+// change them before the node starts, they are safe to change concurrently
+// but a validation in progress may still use the old ones.
+type TransactionLimits struct {
+	SliceCount      int
+	ReferencesCount int
+}
+
+var transactionLimits atomic.Pointer[TransactionLimits]
+
+func DefaultTransactionLimits() TransactionLimits {
+	return TransactionLimits{
+		SliceCount:      SliceCountLimit,
+		ReferencesCount: ReferencesCountLimit,
+	}
+}
+
+func GetTransactionLimits() TransactionLimits {
+	if l := transactionLimits.Load(); l != nil {
+		return *l
+	}
+	return DefaultTransactionLimits()
+}
+
+func SetTransactionLimits(l TransactionLimits) error {
+	if l.SliceCount < 1 || l.ReferencesCount < 0 || l.ReferencesCount > l.SliceCount {
+		return fmt.Errorf("invalid transaction limits %d %d", l.SliceCount, l.ReferencesCount)
+	}
+	transactionLimits.Store(&l)
+	return nil
+}
+
+// CheckResourceLimits checks all the count and size bounds of the transaction
+// without any store, the aggregate of the slices is bounded by the payload size.
+func (tx *Transaction) CheckResourceLimits() error {
+	limits := GetTransactionLimits()
+	if len(tx.Inputs) > limits.SliceCount || len(tx.Outputs) > limits.SliceCount {
+		return fmt.Errorf("invalid tx inputs or outputs %d %d", len(tx.Inputs), len(tx.Outputs))
+	}
+	if len(tx.References) > limits.ReferencesCount {
+		return fmt.Errorf("too many references %d", len(tx.References))
+	}
+	if len(tx.Extra) > ExtraSizeStorageCapacity {
+		return fmt.Errorf("invalid extra size %d", len(tx.Extra))
+	}
+	size := len(tx.AsVersioned().payloadMarshal())
+	if size > config.TransactionMaximumSize {
+		return fmt.Errorf("invalid transaction size %d", size)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/MixinNetwork/mixin/crypto"
 )
@@ -21,6 +22,45 @@ type MintDistribution struct {
 	Transaction crypto.Hash
 }
 
+// Bytes encodes the mint data the same as the mint input of a transaction.
+func (m *MintData) Bytes() []byte {
+	enc := NewMinimumEncoder()
+	enc.WriteInt(len(m.Group))
+	enc.Write([]byte(m.Group))
+	enc.WriteUint64(m.Batch)
+	enc.WriteInteger(m.Amount)
+	return enc.Bytes()
+}
+
+func ParseMintData(b []byte) (*MintData, error) {
+	dec, err := NewMinimumDecoder(b)
+	if err != nil {
+		return nil, err
+	}
+	gb, err := dec.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	m := &MintData{Group: string(gb)}
+	switch m.Group {
+	case mintGroupUniversal:
+	default:
+		return nil, fmt.Errorf("invalid mint group %s", m.Group)
+	}
+	m.Batch, err = dec.ReadUint64()
+	if err != nil {
+		return nil, err
+	}
+	m.Amount, err = dec.ReadInteger()
+	if err != nil {
+		return nil, err
+	}
+	if dec.buf.Len() != 0 {
+		return nil, fmt.Errorf("invalid mint data size %d", len(b))
+	}
+	return m, nil
+}
+
 func (m *MintData) Distribute(tx crypto.Hash) *MintDistribution {
 	return &MintDistribution{
 		MintData:    *m,
@@ -64,6 +104,20 @@ func (tx *VersionedTransaction) validateMint(store DataStore) error {
 	return nil
 }
 
+// MintInfo returns the batch day and amount of a mint transaction, the batch
+// is the days since the epoch day as kernel.MintBatch, not kernel.MintDay.
+func (signed *SignedTransaction) MintInfo() (uint32, Integer, bool) {
+	if signed.TransactionType() != TransactionTypeMint {
+		return 0, Zero, false
+	}
+	for _, in := range signed.Inputs {
+		if in.Mint != nil && in.Mint.Batch <= math.MaxUint32 {
+			return uint32(in.Mint.Batch), in.Mint.Amount, true
+		}
+	}
+	return 0, Zero, false
+}
+
 func (tx *Transaction) AddUniversalMintInput(batch uint64, amount Integer) {
 	tx.Inputs = append(tx.Inputs, &Input{
 		Mint: &MintData{
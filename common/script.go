@@ -4,6 +4,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strconv"
+
+	"github.com/MixinNetwork/mixin/crypto"
 )
 
 const (
@@ -43,6 +45,36 @@ func (s Script) Validate(sum int) error {
 	return nil
 }
 
+func (o *Output) RequiredSignatures() (int, error) {
+	switch o.Type {
+	case OutputTypeScript, OutputTypeNodeRemove:
+	default:
+		return 0, fmt.Errorf("invalid output type %d for script", o.Type)
+	}
+	err := o.Script.VerifyFormat()
+	if err != nil {
+		return 0, err
+	}
+	return int(o.Script[2]), nil
+}
+
+// AddKey appends the ghost key k and bumps the threshold if adjustThreshold,
+// the threshold never exceeds the number of keys or Operator64.
+func (o *Output) AddKey(k *crypto.Key, adjustThreshold bool) {
+	o.Keys = append(o.Keys, k)
+	if o.Script.VerifyFormat() != nil {
+		o.Script = NewThresholdScript(0)
+	} else {
+		o.Script = NewThresholdScript(o.Script[2])
+	}
+	if adjustThreshold && o.Script[2] < Operator64 {
+		o.Script[2] = o.Script[2] + 1
+	}
+	if int(o.Script[2]) > len(o.Keys) {
+		o.Script[2] = uint8(len(o.Keys))
+	}
+}
+
 func (s Script) String() string {
 	return hex.EncodeToString(s[:])
 }
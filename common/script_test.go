@@ -3,6 +3,7 @@ package common
 import (
 	"testing"
 
+	"github.com/MixinNetwork/mixin/crypto"
 	"github.com/stretchr/testify/require"
 )
 
@@ -45,4 +46,36 @@ func TestScript(t *testing.T) {
 	err = s.Validate(1)
 	require.Nil(err)
 	require.Equal("fffe01", s.String())
+
+	out := &Output{Type: OutputTypeScript, Script: NewThresholdScript(2)}
+	n, err := out.RequiredSignatures()
+	require.Nil(err)
+	require.Equal(2, n)
+	out.Script = Script([]byte{OperatorCmp, OperatorCmp, 2})
+	_, err = out.RequiredSignatures()
+	require.NotNil(err)
+	out = &Output{Type: OutputTypeWithdrawalSubmit}
+	_, err = out.RequiredSignatures()
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid output type")
+
+	k1, k2, k3 := crypto.Key{1}, crypto.Key{2}, crypto.Key{3}
+	out = &Output{Type: OutputTypeScript}
+	out.AddKey(&k1, true)
+	require.Equal("fffe01", out.Script.String())
+	out.AddKey(&k2, false)
+	require.Equal("fffe01", out.Script.String())
+	out.AddKey(&k3, true)
+	require.Equal("fffe02", out.Script.String())
+	require.Equal([]*crypto.Key{&k1, &k2, &k3}, out.Keys)
+	shared := NewThresholdScript(5)
+	out = &Output{Type: OutputTypeScript, Script: shared}
+	out.AddKey(&k1, true)
+	require.Equal("fffe01", out.Script.String())
+	require.Equal("fffe05", shared.String())
+	out.Script = NewThresholdScript(Operator64)
+	out.Keys = make([]*crypto.Key, Operator64)
+	out.AddKey(&k2, true)
+	require.Equal(Operator64, int(out.Script[2]))
+	require.Len(out.Keys, Operator64+1)
 }
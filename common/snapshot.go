@@ -33,6 +33,21 @@ type SnapshotWork struct {
 	Signers   []crypto.Hash
 }
 
+// ValidateSigners checks all signers of the work are in the accepted nodes
+// at the snapshot timestamp, otherwise the work shouldn't be credited.
+func (sw *SnapshotWork) ValidateSigners(accepted []crypto.Hash) error {
+	filter := make(map[crypto.Hash]bool, len(accepted))
+	for _, id := range accepted {
+		filter[id] = true
+	}
+	for _, id := range sw.Signers {
+		if !filter[id] {
+			return fmt.Errorf("invalid snapshot work signer %s %s", sw.Hash, id)
+		}
+	}
+	return nil
+}
+
 func (s *Snapshot) SoleTransaction() crypto.Hash {
 	if s.Version < SnapshotVersionCommonEncoding {
 		panic(s.Version)
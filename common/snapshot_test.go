@@ -95,6 +95,23 @@ func TestSnapshot(t *testing.T) {
 	require.Equal(uint64(345), s.TopologicalOrder)
 }
 
+func TestSnapshotWorkSigners(t *testing.T) {
+	require := require.New(t)
+
+	n1 := crypto.Blake3Hash([]byte("node-1"))
+	n2 := crypto.Blake3Hash([]byte("node-2"))
+	n3 := crypto.Blake3Hash([]byte("node-3"))
+	sw := &SnapshotWork{Hash: crypto.Blake3Hash([]byte("snapshot")), Signers: []crypto.Hash{n2, n1}}
+	require.Nil(sw.ValidateSigners([]crypto.Hash{n1, n2, n3}))
+	require.Nil(sw.ValidateSigners([]crypto.Hash{n1, n2}))
+	err := sw.ValidateSigners([]crypto.Hash{n1, n3})
+	require.NotNil(err)
+	require.Contains(err.Error(), n2.String())
+	require.NotNil(sw.ValidateSigners(nil))
+	sw.Signers = nil
+	require.Nil(sw.ValidateSigners(nil))
+}
+
 func BenchmarkSnapshotMarshal(b *testing.B) {
 	s := &SnapshotWithTopologicalOrder{Snapshot: &Snapshot{Version: SnapshotVersionCommonEncoding}}
 	s.Transactions = []crypto.Hash{crypto.Blake3Hash([]byte("tx-test-id"))}
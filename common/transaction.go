@@ -1,9 +1,14 @@
 package common
 
 import (
+	"bytes"
+	"cmp"
+	"context"
 	"crypto/sha512"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"slices"
 
 	"filippo.io/edwards25519"
 	"github.com/MixinNetwork/mixin/crypto"
@@ -16,9 +21,12 @@ const (
 	ExtraSizeStorageStep     = 1024
 	ExtraSizeStorageCapacity = 1024 * 1024 * 4
 	ExtraStoragePriceStep    = "0.0001"
+	TransactionBaseFee       = "0.0001"
 	SliceCountLimit          = 256
 	ReferencesCountLimit     = 16
 
+	// the resign types are legacy only, v5 rejects them, and nodes now leave
+	// by the kernel removal, so there is no resign transaction to build
 	OutputTypeScript               = 0x00
 	OutputTypeWithdrawalSubmit     = 0xa1
 	OutputTypeNodePledge           = 0xa3
@@ -81,6 +89,7 @@ type SignedTransaction struct {
 
 func (tx *Transaction) ViewGhostKey(a *crypto.Key) []*Output {
 	outputs := make([]*Output, 0)
+	shared := make(map[crypto.Key]*edwards25519.Point)
 
 	for i, o := range tx.Outputs {
 		if o.Type != OutputTypeScript {
@@ -93,9 +102,8 @@ func (tx *Transaction) ViewGhostKey(a *crypto.Key) []*Output {
 			Script: o.Script,
 			Mask:   o.Mask,
 		}
-		for _, k := range o.Keys {
-			key := crypto.ViewGhostOutputKey(k, a, &o.Mask, uint64(i))
-			out.Keys = append(out.Keys, key)
+		if len(o.Keys) > 0 {
+			out.Keys = crypto.ViewGhostOutputKeys(o.Keys, viewSharedPoint(shared, a, &o.Mask), uint64(i))
 		}
 		outputs = append(outputs, out)
 	}
@@ -103,6 +111,71 @@ func (tx *Transaction) ViewGhostKey(a *crypto.Key) []*Output {
 	return outputs
 }
 
+// viewSharedPoint caches a*R by the mask R, outputs with the same mask only
+// differ in the output index and could reuse it.
+func viewSharedPoint(shared map[crypto.Key]*edwards25519.Point, a, R *crypto.Key) *edwards25519.Point {
+	aR := shared[*R]
+	if aR == nil {
+		aR = crypto.KeyMultPubPriv(R, a)
+		shared[*R] = aR
+	}
+	return aR
+}
+
+func (tx *Transaction) OwnedOutputs(a, B *crypto.Key) []int {
+	var owned []int
+	shared := make(map[crypto.Key]*edwards25519.Point)
+	for i, o := range tx.Outputs {
+		if o.Type != OutputTypeScript || len(o.Keys) == 0 {
+			continue
+		}
+		keys := crypto.ViewGhostOutputKeys(o.Keys, viewSharedPoint(shared, a, &o.Mask), uint64(i))
+		for _, key := range keys {
+			if *key == *B {
+				owned = append(owned, i)
+				break
+			}
+		}
+	}
+	return owned
+}
+
+// AllOutputKeys returns the one-time keys of all script outputs in order.
+func (tx *Transaction) AllOutputKeys() []*crypto.Key {
+	var keys []*crypto.Key
+	for _, o := range tx.Outputs {
+		if o.Type != OutputTypeScript {
+			continue
+		}
+		keys = append(keys, o.Keys...)
+	}
+	return keys
+}
+
+type OwnedOutput struct {
+	Index      int
+	Amount     Integer
+	Mask       crypto.Key
+	PrivateKey *crypto.Key
+}
+
+// ScanOwned returns the script outputs owned by a, with the derived one-time
+// private keys ready to sign the inputs spending them.
+func (tx *Transaction) ScanOwned(a *Address) []OwnedOutput {
+	var owned []OwnedOutput
+	for _, i := range tx.OwnedOutputs(&a.PrivateViewKey, &a.PublicSpendKey) {
+		o := tx.Outputs[i]
+		priv := crypto.DeriveGhostPrivateKey(&o.Mask, &a.PrivateViewKey, &a.PrivateSpendKey, uint64(i))
+		owned = append(owned, OwnedOutput{
+			Index:      i,
+			Amount:     o.Amount,
+			Mask:       o.Mask,
+			PrivateKey: priv,
+		})
+	}
+	return owned
+}
+
 func (tx *SignedTransaction) TransactionType() uint8 {
 	for _, in := range tx.Inputs {
 		if in.Mint != nil {
@@ -145,12 +218,241 @@ func (tx *SignedTransaction) TransactionType() uint8 {
 	return TransactionTypeUnknown
 }
 
+// IsFeeExemptTransactionType reports whether the transaction type is free,
+// the mint, deposit and node operations are created by the kernel or the
+// custodian, while the script and withdrawal transactions are fee-bearing.
+// An unknown type is never accepted, so it's fee-bearing to not look free.
+func IsFeeExemptTransactionType(t uint8) bool {
+	switch t {
+	case TransactionTypeMint,
+		TransactionTypeDeposit,
+		TransactionTypeNodePledge,
+		TransactionTypeNodeAccept,
+		transactionTypeNodeResign,
+		TransactionTypeNodeRemove,
+		TransactionTypeNodeCancel,
+		TransactionTypeCustodianUpdateNodes,
+		TransactionTypeCustodianSlashNodes:
+		return true
+	case TransactionTypeScript,
+		TransactionTypeWithdrawalSubmit,
+		TransactionTypeWithdrawalClaim,
+		TransactionTypeUnknown:
+		return false
+	}
+	return false
+}
+
+func (signed *SignedTransaction) Unsigned() *Transaction {
+	tx := signed.Transaction
+	tx.Inputs = make([]*Input, len(signed.Inputs))
+	for i, in := range signed.Inputs {
+		tx.Inputs[i] = in.Copy()
+	}
+	tx.Outputs = make([]*Output, len(signed.Outputs))
+	for i, out := range signed.Outputs {
+		tx.Outputs[i] = out.Copy()
+	}
+	tx.References = slices.Clone(signed.References)
+	tx.Extra = slices.Clone(signed.Extra)
+	return &tx
+}
+
+func (in *Input) Copy() *Input {
+	c := *in
+	c.Genesis = slices.Clone(in.Genesis)
+	if in.Deposit != nil {
+		d := *in.Deposit
+		c.Deposit = &d
+	}
+	if in.Mint != nil {
+		m := *in.Mint
+		c.Mint = &m
+	}
+	return &c
+}
+
+func (o *Output) Copy() *Output {
+	c := *o
+	c.Keys = make([]*crypto.Key, len(o.Keys))
+	for i, k := range o.Keys {
+		key := *k
+		c.Keys[i] = &key
+	}
+	c.Script = slices.Clone(o.Script)
+	if o.Withdrawal != nil {
+		w := *o.Withdrawal
+		c.Withdrawal = &w
+	}
+	return &c
+}
+
+func (signed *SignedTransaction) ClearSignatures() {
+	signed.SignaturesMap = nil
+	signed.AggregatedSignature = nil
+}
+
+type InputProgress struct {
+	Have int
+	Need int
+}
+
+func (signed *SignedTransaction) SignatureProgress(reader UTXOKeysReader) ([]InputProgress, error) {
+	progress := make([]InputProgress, len(signed.Inputs))
+	offset := 0
+	for i, in := range signed.Inputs {
+		if i < len(signed.SignaturesMap) {
+			progress[i].Have = len(signed.SignaturesMap[i])
+		}
+		if in.Deposit != nil || in.Mint != nil {
+			progress[i].Need = 1
+			continue
+		}
+
+		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
+		if err != nil {
+			return nil, err
+		}
+		if utxo == nil {
+			return nil, fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
+		}
+		err = utxo.Script.VerifyFormat()
+		if err != nil {
+			return nil, err
+		}
+		progress[i].Need = int(utxo.Script[2])
+
+		limit := offset + len(utxo.Keys)
+		if as := signed.AggregatedSignature; as != nil {
+			for _, m := range as.Signers {
+				if m >= offset && m < limit {
+					progress[i].Have += 1
+				}
+			}
+		}
+		offset = limit
+	}
+	return progress, nil
+}
+
+// IsComplete reports whether all inputs have the signatures required by their
+// thresholds, the signatures are only counted, Validate still verifies them.
+func (signed *SignedTransaction) IsComplete(reader UTXOKeysReader) (bool, error) {
+	progress, err := signed.SignatureProgress(reader)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range progress {
+		if p.Have < p.Need {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// CheckSignatureReuse reports whether the same signature appears more than
+// once in the signatures map, a nonce reuse bug that could leak the keys.
+func (signed *SignedTransaction) CheckSignatureReuse() (bool, error) {
+	filter := make(map[crypto.Signature]bool)
+	for i, sigs := range signed.SignaturesMap {
+		for k, sig := range sigs {
+			if sig == nil {
+				return false, fmt.Errorf("invalid signature %d:%d", i, k)
+			}
+			if filter[*sig] {
+				return true, nil
+			}
+			filter[*sig] = true
+		}
+	}
+	return false, nil
+}
+
+func (signed *SignedTransaction) ValidateAssetConsistency(reader UTXOKeysReader) error {
+	for _, in := range signed.Inputs {
+		if in.Deposit != nil || in.Mint != nil || in.Genesis != nil {
+			continue
+		}
+		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
+		if err != nil {
+			return err
+		}
+		if utxo == nil {
+			return fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
+		}
+		if utxo.Asset != signed.Asset {
+			return fmt.Errorf("invalid input asset %s:%d %s %s", in.Hash.String(), in.Index, utxo.Asset.String(), signed.Asset.String())
+		}
+	}
+	return nil
+}
+
+// CheckInputsUnspent returns the indexes of inputs not found by the reader,
+// which are spent or unknown, any other reader error is returned as is.
+func (signed *SignedTransaction) CheckInputsUnspent(reader UTXOKeysReader) (spent []int, err error) {
+	for i, in := range signed.Inputs {
+		if in.Deposit != nil || in.Mint != nil || in.Genesis != nil {
+			continue
+		}
+		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
+		if err != nil {
+			return nil, err
+		}
+		if utxo == nil {
+			spent = append(spent, i)
+		}
+	}
+	return spent, nil
+}
+
+// CoverableInputs returns the indexes of inputs whose thresholds could be met
+// by the accounts alone, the deposit and mint inputs are skipped.
+func (signed *SignedTransaction) CoverableInputs(reader UTXOKeysReader, accounts []*Address) ([]int, error) {
+	var coverable []int
+	for i, in := range signed.Inputs {
+		if in.Deposit != nil || in.Mint != nil || in.Genesis != nil {
+			continue
+		}
+		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
+		if err != nil {
+			return nil, err
+		}
+		if utxo == nil {
+			return nil, fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
+		}
+		err = utxo.Script.VerifyFormat()
+		if err != nil {
+			return nil, err
+		}
+
+		keysFilter := make(map[crypto.Key]bool)
+		for _, k := range utxo.Keys {
+			keysFilter[*k] = true
+		}
+		signers := make(map[crypto.Key]bool)
+		for _, acc := range accounts {
+			priv := crypto.DeriveGhostPrivateKey(&utxo.Mask, &acc.PrivateViewKey, &acc.PrivateSpendKey, uint64(in.Index))
+			if pub := priv.Public(); keysFilter[pub] {
+				signers[pub] = true
+			}
+		}
+		if len(signers) >= int(utxo.Script[2]) {
+			coverable = append(coverable, i)
+		}
+	}
+	return coverable, nil
+}
+
 func (signed *SignedTransaction) SignUTXO(utxo *UTXO, accounts []*Address) error {
 	msg := signed.AsVersioned().PayloadHash()
 
 	if len(accounts) == 0 {
 		return nil
 	}
+	err := validateGhostKeys(&utxo.Mask, utxo.Keys)
+	if err != nil {
+		return err
+	}
 
 	keysFilter := make(map[string]uint16)
 	for i, k := range utxo.Keys {
@@ -159,6 +461,9 @@ func (signed *SignedTransaction) SignUTXO(utxo *UTXO, accounts []*Address) error
 
 	sigs := make(map[uint16]*crypto.Signature)
 	for _, acc := range accounts {
+		if err := acc.checkSigner(); err != nil {
+			return err
+		}
 		priv := crypto.DeriveGhostPrivateKey(&utxo.Mask, &acc.PrivateViewKey, &acc.PrivateSpendKey, uint64(utxo.Index))
 		i, found := keysFilter[priv.Public().String()]
 		if !found {
@@ -178,6 +483,11 @@ func (signed *SignedTransaction) SignInput(reader UTXOKeysReader, index int, acc
 	if index >= len(signed.Inputs) {
 		return fmt.Errorf("invalid input index %d/%d", index, len(signed.Inputs))
 	}
+	for _, acc := range accounts {
+		if err := acc.checkSigner(); err != nil {
+			return err
+		}
+	}
 	in := signed.Inputs[index]
 	if in.Deposit != nil || in.Mint != nil {
 		return signed.SignRaw(accounts[0].PrivateSpendKey)
@@ -190,6 +500,10 @@ func (signed *SignedTransaction) SignInput(reader UTXOKeysReader, index int, acc
 	if utxo == nil {
 		return fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
 	}
+	err = validateGhostKeys(&utxo.Mask, utxo.Keys)
+	if err != nil {
+		return err
+	}
 
 	keysFilter := make(map[string]uint16)
 	for i, k := range utxo.Keys {
@@ -228,10 +542,17 @@ func (signed *SignedTransaction) SignRaw(key crypto.Key) error {
 }
 
 func (signed *SignedTransaction) AggregateSign(reader UTXOKeysReader, accounts [][]*Address, seed []byte) error {
+	return signed.AggregateSignContext(context.Background(), reader, accounts, seed)
+}
+
+func (signed *SignedTransaction) AggregateSignContext(ctx context.Context, reader UTXOKeysReader, accounts [][]*Address, seed []byte) error {
 	var signers []int
-	var randoms []*crypto.Key
-	var pubKeys, privKeys []*crypto.Key
+	var privKeys []*crypto.Key
+	offset := 0
 	for index, in := range signed.Inputs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
 		if err != nil {
 			return err
@@ -239,72 +560,95 @@ func (signed *SignedTransaction) AggregateSign(reader UTXOKeysReader, accounts [
 		if utxo == nil {
 			return fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
 		}
+		err = validateGhostKeys(&utxo.Mask, utxo.Keys)
+		if err != nil {
+			return err
+		}
 
 		keysFilter := make(map[string]int)
 		for i, k := range utxo.Keys {
 			keysFilter[k.String()] = i
 		}
 
+		// accounts of the input could be in any order, the signers are sorted
+		// by the key index, and the inputs are in order already
+		var members []int
+		keys := make(map[int]*crypto.Key)
 		for _, acc := range accounts[index] {
+			if err := acc.checkSigner(); err != nil {
+				return err
+			}
 			priv := crypto.DeriveGhostPrivateKey(&utxo.Mask, &acc.PrivateViewKey, &acc.PrivateSpendKey, uint64(in.Index))
 			i, found := keysFilter[priv.Public().String()]
 			if !found {
 				return fmt.Errorf("invalid key for the input %s", acc.String())
 			}
-			m := len(pubKeys) + i
-			if sl := len(signers); sl > 0 && m <= signers[sl-1] {
-				return fmt.Errorf("invalid signers order %d %d", signers[sl-1], m)
+			m := offset + i
+			if keys[m] != nil {
+				return fmt.Errorf("duplicate signer %d for the input %s", m, acc.String())
 			}
+			keys[m] = priv
+			members = append(members, m)
+		}
+		slices.Sort(members)
+		for _, m := range members {
 			signers = append(signers, m)
-			privKeys = append(privKeys, priv)
+			privKeys = append(privKeys, keys[m])
 		}
-		pubKeys = append(pubKeys, utxo.Keys...)
+		offset += len(utxo.Keys)
+	}
+
+	msg := signed.AsVersioned().PayloadHash()
+	as, err := aggregateSign(ctx, msg, privKeys, signers, seed)
+	if err != nil {
+		return err
 	}
+	signed.AggregatedSignature = as
+	return nil
+}
 
+// aggregateSign signs msg with the private keys of the sorted signers, the
+// public keys must be the publics of the signers in the verification.
+func aggregateSign(ctx context.Context, msg crypto.Hash, privKeys []*crypto.Key, signers []int, seed []byte) (*AggregatedSignature, error) {
+	var randoms []*crypto.Key
 	P := edwards25519.NewIdentityPoint()
 	A := edwards25519.NewIdentityPoint()
-	for _, m := range signers {
-		buf := binary.BigEndian.AppendUint16(seed, uint16(m))
-		s := crypto.Blake3Hash(buf)
-		r := crypto.NewKeyFromSeed(append(s[:], s[:]...))
+	for i, m := range signers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		r := AggregateNonce(seed, m)
 		randoms = append(randoms, &r)
 		R := r.Public()
 
 		p, err := edwards25519.NewIdentityPoint().SetBytes(R[:])
 		if err != nil {
-			return err
+			return nil, err
 		}
 		P = P.Add(P, p)
 
-		pub := pubKeys[m]
+		pub := privKeys[i].Public()
 		a, err := edwards25519.NewIdentityPoint().SetBytes(pub[:])
 		if err != nil {
-			return err
+			return nil, err
 		}
 		A = A.Add(A, a)
 	}
 
-	var hramDigest [64]byte
-	msg := signed.AsVersioned().PayloadHash()
-	h := sha512.New()
-	h.Write(P.Bytes())
-	h.Write(A.Bytes())
-	h.Write(msg[:])
-	h.Sum(hramDigest[:0])
-	x, err := edwards25519.NewScalar().SetUniformBytes(hramDigest[:])
+	x, err := aggregateChallenge(P, A, msg)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	S := edwards25519.NewScalar()
 	for i, k := range privKeys {
 		y, err := edwards25519.NewScalar().SetCanonicalBytes(k[:])
 		if err != nil {
-			panic(k.String())
+			return nil, fmt.Errorf("invalid aggregate private key %d %w", i, err)
 		}
 		z, err := edwards25519.NewScalar().SetCanonicalBytes(randoms[i][:])
 		if err != nil {
-			panic(randoms[i].String())
+			return nil, fmt.Errorf("invalid aggregate random %d %w", i, err)
 		}
 		s := edwards25519.NewScalar().MultiplyAdd(x, y, z)
 		S = S.Add(S, s)
@@ -313,8 +657,23 @@ func (signed *SignedTransaction) AggregateSign(reader UTXOKeysReader, accounts [
 	as := &AggregatedSignature{Signers: signers}
 	copy(as.Signature[:32], P.Bytes())
 	copy(as.Signature[32:], S.Bytes())
-	signed.AggregatedSignature = as
-	return nil
+	return as, nil
+}
+
+func AggregateNonce(seed []byte, m int) crypto.Key {
+	buf := binary.BigEndian.AppendUint16(seed, uint16(m))
+	s := crypto.NonceHash(buf)
+	return crypto.NewKeyFromSeed(append(s[:], s[:]...))
+}
+
+func aggregateChallenge(P, A *edwards25519.Point, msg crypto.Hash) (*edwards25519.Scalar, error) {
+	var hramDigest [64]byte
+	h := sha512.New()
+	h.Write(P.Bytes())
+	h.Write(A.Bytes())
+	h.Write(msg[:])
+	h.Sum(hramDigest[:0])
+	return edwards25519.NewScalar().SetUniformBytes(hramDigest[:])
 }
 
 func NewTransactionV5(asset crypto.Hash) *Transaction {
@@ -332,6 +691,93 @@ func (tx *Transaction) AddInput(hash crypto.Hash, index uint) {
 	tx.Inputs = append(tx.Inputs, in)
 }
 
+// Canonicalize sorts the inputs by hash and index, it must be called before
+// signing because the payload hash is order sensitive. The outputs order is
+// kept as is, the ghost keys are derived with the output index. The protocol
+// accepts any order, reordering changes the hash and breaks the signatures,
+// so the order is never malleable by others.
+func (tx *Transaction) Canonicalize() {
+	slices.SortStableFunc(tx.Inputs, func(a, b *Input) int {
+		c := bytes.Compare(a.Hash[:], b.Hash[:])
+		if c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Index, b.Index)
+	})
+}
+
+// ConflictsWith reports whether both transactions spend any same UTXO, or
+// claim the same deposit or mint batch, only one of them could be finalized.
+func (signed *SignedTransaction) ConflictsWith(other *SignedTransaction) bool {
+	filter := make(map[string]bool)
+	for _, in := range signed.Inputs {
+		filter[in.conflictKey()] = true
+	}
+	for _, in := range other.Inputs {
+		if filter[in.conflictKey()] {
+			return true
+		}
+	}
+	return false
+}
+
+func (in *Input) conflictKey() string {
+	switch {
+	case in.Deposit != nil:
+		return "DEPOSIT:" + in.Deposit.UniqueKey().String()
+	case in.Mint != nil:
+		return fmt.Sprintf("MINT:%s:%d", in.Mint.Group, in.Mint.Batch)
+	}
+	return fmt.Sprintf("%s:%d", in.Hash.String(), in.Index)
+}
+
+// HasDuplicateInputs returns the first UTXO referenced more than once by the inputs.
+func (tx *Transaction) HasDuplicateInputs() (crypto.Hash, uint, bool) {
+	filter := make(map[string]bool)
+	for _, in := range tx.Inputs {
+		if in.Mint != nil || in.Deposit != nil || len(in.Genesis) > 0 {
+			continue
+		}
+		fk := fmt.Sprintf("%s:%d", in.Hash.String(), in.Index)
+		if filter[fk] {
+			return in.Hash, in.Index, true
+		}
+		filter[fk] = true
+	}
+	return crypto.Hash{}, 0, false
+}
+
+// CheckMasksDistinct errors on two script outputs with the same mask, which
+// means the outputs are built from a reused seed.
+func (tx *Transaction) CheckMasksDistinct() error {
+	filter := make(map[crypto.Key]int)
+	for i, o := range tx.Outputs {
+		if o.Type != OutputTypeScript || !o.Mask.HasValue() {
+			continue
+		}
+		if j, found := filter[o.Mask]; found {
+			return fmt.Errorf("duplicate output mask %s %d %d", o.Mask, j, i)
+		}
+		filter[o.Mask] = i
+	}
+	return nil
+}
+
+// DistinctRecipientMasks returns the unique masks of the script outputs in the
+// outputs order, those without a mask are skipped.
+func (tx *Transaction) DistinctRecipientMasks() []crypto.Key {
+	var masks []crypto.Key
+	filter := make(map[crypto.Key]bool)
+	for _, o := range tx.Outputs {
+		if o.Type != OutputTypeScript || !o.Mask.HasValue() || filter[o.Mask] {
+			continue
+		}
+		filter[o.Mask] = true
+		masks = append(masks, o.Mask)
+	}
+	return masks
+}
+
 func (tx *Transaction) AddOutputWithType(ot uint8, accounts []*Address, s Script, amount Integer, seed []byte) {
 	out := &Output{
 		Type:   ot,
@@ -352,12 +798,138 @@ func (tx *Transaction) AddOutputWithType(ot uint8, accounts []*Address, s Script
 	tx.Outputs = append(tx.Outputs, out)
 }
 
+// RetargetOutput regenerates the mask and keys of the script output at index
+// for the accounts, the amount and script are kept.
+func (tx *Transaction) RetargetOutput(index int, accounts []*Address, seed []byte) error {
+	if index < 0 || index >= len(tx.Outputs) {
+		return fmt.Errorf("invalid output index %d/%d", index, len(tx.Outputs))
+	}
+	out := tx.Outputs[index]
+	if out.Type != OutputTypeScript || len(out.Keys) == 0 {
+		return fmt.Errorf("invalid output to retarget %d %d", index, out.Type)
+	}
+	if len(accounts) == 0 {
+		return fmt.Errorf("invalid accounts count %d", len(accounts))
+	}
+	r := crypto.NewKeyFromSeed(seed)
+	keys := make([]*crypto.Key, 0)
+	for _, a := range accounts {
+		k := crypto.DeriveGhostPublicKey(&r, &a.PublicViewKey, &a.PublicSpendKey, uint64(index))
+		keys = append(keys, k)
+	}
+	out.Mask = r.Public()
+	out.Keys = keys
+	return nil
+}
+
+// RetargetOutput is only allowed before any signature, which would be invalid.
+func (signed *SignedTransaction) RetargetOutput(index int, accounts []*Address, seed []byte) error {
+	if len(signed.SignaturesMap) > 0 || signed.AggregatedSignature != nil {
+		return fmt.Errorf("retarget output %d of signed transaction", index)
+	}
+	return signed.Transaction.RetargetOutput(index, accounts, seed)
+}
+
 func (tx *Transaction) AddScriptOutput(accounts []*Address, s Script, amount Integer, seed []byte) {
 	tx.AddOutputWithType(OutputTypeScript, accounts, s, amount, seed)
 }
 
 func (tx *Transaction) AddRandomScriptOutput(accounts []*Address, s Script, amount Integer) {
+	tx.AddScriptOutputWithRand(accounts, s, amount, crypto.RandReader())
+}
+
+// AddScriptOutputWithRand reads the output seed from rand, a deterministic
+// reader makes the output reproducible.
+func (tx *Transaction) AddScriptOutputWithRand(accounts []*Address, s Script, amount Integer, rand io.Reader) {
 	seed := make([]byte, 64)
-	crypto.ReadRand(seed)
+	_, err := io.ReadFull(rand, seed)
+	if err != nil {
+		panic(err)
+	}
 	tx.AddScriptOutput(accounts, s, amount, seed)
 }
+
+// BuildSpend selects inputs from utxos to pay amount to the recipient, and
+// sends any remaining amount back to the change accounts, both outputs use a
+// threshold 1 script. The transaction is returned unsigned.
+func BuildSpend(asset crypto.Hash, utxos []*UTXO, recipient []*Address, amount Integer, change []*Address) (*SignedTransaction, error) {
+	if len(recipient) == 0 {
+		return nil, fmt.Errorf("invalid recipient accounts count %d", len(recipient))
+	}
+	for _, u := range utxos {
+		if u.Asset != asset {
+			return nil, fmt.Errorf("invalid utxo asset %s %s", u.Asset, asset)
+		}
+	}
+	inputs, rest, err := SelectUTXOs(utxos, amount, GetTransactionLimits().SliceCount)
+	if err != nil {
+		return nil, err
+	}
+	if rest.Sign() > 0 && len(change) == 0 {
+		return nil, fmt.Errorf("invalid change accounts count %d for %s", len(change), rest)
+	}
+
+	tx := NewTransactionV5(asset)
+	for _, in := range inputs {
+		tx.AddInput(in.Hash, in.Index)
+	}
+	script := NewThresholdScript(1)
+	tx.AddRandomScriptOutput(recipient, script, amount)
+	if rest.Sign() > 0 {
+		tx.AddRandomScriptOutput(change, script, rest)
+	}
+	return &SignedTransaction{Transaction: *tx}, nil
+}
+
+// BuildAndSign is BuildSpend and signs all the inputs, accounts[i] are the
+// signers of utxos[i], it returns either a fully signed transaction or error.
+func BuildAndSign(asset crypto.Hash, utxos []*UTXO, recipient []*Address, amount Integer, change []*Address, accounts [][]*Address) (*SignedTransaction, error) {
+	if len(accounts) != len(utxos) {
+		return nil, fmt.Errorf("invalid signer accounts count %d %d", len(accounts), len(utxos))
+	}
+	signers := make(map[string][]*Address, len(utxos))
+	for i, u := range utxos {
+		signers[fmt.Sprintf("%s:%d", u.Hash.String(), u.Index)] = accounts[i]
+	}
+
+	signed, err := BuildSpend(asset, utxos, recipient, amount, change)
+	if err != nil {
+		return nil, err
+	}
+	reader := utxoSliceReader(utxos)
+	for i, in := range signed.Inputs {
+		accs := signers[fmt.Sprintf("%s:%d", in.Hash.String(), in.Index)]
+		if len(accs) == 0 {
+			return nil, fmt.Errorf("no signer accounts for the input %s:%d", in.Hash.String(), in.Index)
+		}
+		err = signed.SignInput(reader, i, accs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	complete, err := signed.IsComplete(reader)
+	if err != nil {
+		return nil, err
+	}
+	if !complete {
+		return nil, fmt.Errorf("insufficient signatures for %s", signed.AsVersioned().PayloadHash())
+	}
+	return signed, nil
+}
+
+type utxoSliceReader []*UTXO
+
+func (r utxoSliceReader) ReadUTXOKeys(hash crypto.Hash, index uint) (*UTXOKeys, error) {
+	for _, u := range r {
+		if u.Hash == hash && u.Index == index {
+			return &UTXOKeys{
+				Mask:   u.Mask,
+				Keys:   u.Keys,
+				Script: u.Script,
+				Asset:  u.Asset,
+				Amount: u.Amount,
+			}, nil
+		}
+	}
+	return nil, nil
+}
@@ -3,6 +3,9 @@ package common
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
 	"testing"
 	"time"
 
@@ -66,6 +69,13 @@ func TestTransactionReferences(t *testing.T) {
 	for i := range ver.Inputs {
 		err := ver.SignInput(store, i, accounts[0:i+1])
 		require.Nil(err)
+		progress, err := ver.SignatureProgress(store)
+		require.Nil(err)
+		require.Equal(InputProgress{i + 1, i + 1}, progress[i])
+		complete, err := ver.IsComplete(store)
+		require.Nil(err)
+		require.Equal(i == len(ver.Inputs)-1, complete)
+		require.Nil(ver.ValidateAssetConsistency(store))
 		err = ver.Validate(store, uint64(time.Now().UnixNano()), false)
 		if i < len(ver.Inputs)-1 {
 			require.NotNil(err)
@@ -75,6 +85,77 @@ func TestTransactionReferences(t *testing.T) {
 	}
 	err = ver.Validate(store, uint64(time.Now().UnixNano()), false)
 	require.Nil(err)
+	btc := NewTransactionV5(BitcoinAssetId)
+	btc.AddInput(genesisHash, 0)
+	err = btc.AsVersioned().ValidateAssetConsistency(store)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid input asset")
+
+	other := NewTransactionV5(XINAssetId)
+	other.AddInput(genesisHash, 2)
+	require.False(other.AsVersioned().ConflictsWith(&ver.SignedTransaction))
+	other.AddInput(genesisHash, 0)
+	require.True(ver.ConflictsWith(&other.AsVersioned().SignedTransaction))
+	m1, m2 := NewTransactionV5(XINAssetId), NewTransactionV5(XINAssetId)
+	m1.AddUniversalMintInput(1707, NewInteger(1))
+	m2.AddUniversalMintInput(1708, NewInteger(1))
+	require.False(m1.AsVersioned().ConflictsWith(&m2.AsVersioned().SignedTransaction))
+	m2.AddUniversalMintInput(1707, NewInteger(2))
+	require.True(m1.AsVersioned().ConflictsWith(&m2.AsVersioned().SignedTransaction))
+	day, amount, ok := m1.AsVersioned().MintInfo()
+	require.True(ok)
+	require.Equal(uint32(1707), day)
+	require.Equal(NewInteger(1), amount)
+	_, _, ok = other.AsVersioned().MintInfo()
+	require.False(ok)
+	m3 := NewTransactionV5(XINAssetId)
+	m3.AddUniversalMintInput(math.MaxUint32+1, NewInteger(1))
+	_, _, ok = m3.AsVersioned().MintInfo()
+	require.False(ok)
+
+	_, _, dup := ver.HasDuplicateInputs()
+	require.False(dup)
+	dt := NewTransactionV5(XINAssetId)
+	dt.AddInput(genesisHash, 1)
+	dt.AddInput(genesisHash, 0)
+	dt.AddInput(genesisHash, 1)
+	dh, di, dup := dt.HasDuplicateInputs()
+	require.True(dup)
+	require.Equal(genesisHash, dh)
+	require.Equal(uint(1), di)
+	require.Nil(ver.CheckMasksDistinct())
+	mt := NewTransactionV5(XINAssetId)
+	mt.AddScriptOutput(accounts[:1], NewThresholdScript(1), NewInteger(1), seed)
+	for range 2 {
+		mt.Outputs = append(mt.Outputs, &Output{Type: OutputTypeScript, Amount: NewInteger(1), Script: NewThresholdScript(1)})
+	}
+	require.Nil(mt.CheckMasksDistinct())
+	mt.AddScriptOutput(accounts[1:2], NewThresholdScript(1), NewInteger(1), seed)
+	err = mt.CheckMasksDistinct()
+	require.NotNil(err)
+	require.Contains(err.Error(), "duplicate output mask")
+	mt.AddScriptOutput(accounts[1:2], NewThresholdScript(1), NewInteger(1), bytes.Repeat([]byte{2}, 64))
+	require.Equal([]crypto.Key{mt.Outputs[0].Mask, mt.Outputs[4].Mask}, mt.DistinctRecipientMasks())
+	require.Nil(NewTransactionV5(XINAssetId).DistinctRecipientMasks())
+	dt.Outputs = ver.Outputs
+	dv := dt.AsVersioned()
+	dv.SignaturesMap = make([]map[uint16]*crypto.Signature, 3)
+	err = dv.Validate(store, uint64(time.Now().UnixNano()), false)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid input "+genesisHash.String()+":1")
+
+	reused, err := ver.CheckSignatureReuse()
+	require.Nil(err)
+	require.False(reused)
+	signatures := ver.SignaturesMap
+	ver.SignaturesMap = []map[uint16]*crypto.Signature{signatures[0], {0: signatures[1][0], 1: signatures[0][0]}}
+	reused, err = ver.CheckSignatureReuse()
+	require.Nil(err)
+	require.True(reused)
+	ver.SignaturesMap = []map[uint16]*crypto.Signature{signatures[0], {0: nil}}
+	_, err = ver.CheckSignatureReuse()
+	require.NotNil(err)
+	ver.SignaturesMap = signatures
 
 	pm = ver.Marshal()
 	require.Len(pm, 942)
@@ -180,11 +261,10 @@ func TestTransactionReferences(t *testing.T) {
 	}
 	ver.AggregatedSignature = nil
 	err = ver.AggregateSign(store, aas, seed)
-	require.NotNil(err)
-	require.Nil(ver.AggregatedSignature)
-	require.NotNil(ver.Marshal())
+	require.Nil(err)
+	require.Equal([]int{0, 2, 3}, ver.AggregatedSignature.Signers)
 	err = ver.Validate(store, uint64(time.Now().UnixNano()), false)
-	require.NotNil(err)
+	require.Nil(err)
 
 	aas = make([][]*Address, len(ver.Inputs))
 	for i := range ver.Inputs {
@@ -226,6 +306,334 @@ func TestTransactionReferences(t *testing.T) {
 	ver, _ = UnmarshalVersionedTransaction(pm)
 	require.Len(ver.References, 1)
 	require.Equal(ver.Inputs[0].Hash, ver.References[0])
+
+	require.NotEqual(ver.PayloadHash(), ver.Hash())
+	require.Equal(crypto.Blake3Hash(pm), ver.Hash())
+	ver.AggregatedSignature = nil
+	require.Equal(ver.PayloadHash(), ver.Hash())
+
+	ver, _ = UnmarshalVersionedTransaction(pm)
+	unsigned := ver.Unsigned()
+	require.Equal(ver.PayloadHash(), unsigned.AsVersioned().PayloadHash())
+	unsigned.References = append(unsigned.References, crypto.Hash{})
+	require.Len(ver.References, 1)
+	hash := ver.PayloadHash()
+	unsigned.Outputs[0].Amount = NewInteger(12345)
+	unsigned.Outputs[0].Mask = crypto.Key{}
+	*unsigned.Outputs[1].Keys[0] = crypto.Key{}
+	unsigned.Outputs[0].Script[0] = OperatorSum
+	unsigned.Inputs[0].Index = 1024
+	require.Equal(hash, ver.PayloadHash())
+	require.NotNil(ver.AggregatedSignature)
+	ver.ClearSignatures()
+	require.Nil(ver.AggregatedSignature)
+	require.Nil(ver.SignaturesMap)
+	require.Equal(ver.PayloadMarshal(), ver.Marshal())
+}
+
+func TestOwnedOutputs(t *testing.T) {
+	require := require.New(t)
+
+	sender, receiver := randomAccount(), randomAccount()
+	script := NewThresholdScript(1)
+
+	tx := NewTransactionV5(XINAssetId)
+	tx.AddInput(crypto.Hash{}, 0)
+	tx.AddRandomScriptOutput([]*Address{&receiver}, script, NewInteger(100))
+	tx.AddRandomScriptOutput([]*Address{&sender}, script, NewInteger(20))
+	tx.AddRandomScriptOutput([]*Address{&receiver, &sender}, script, NewInteger(5))
+
+	owned := tx.OwnedOutputs(&sender.PrivateViewKey, &sender.PublicSpendKey)
+	require.Equal([]int{1, 2}, owned)
+	owned = tx.OwnedOutputs(&receiver.PrivateViewKey, &receiver.PublicSpendKey)
+	require.Equal([]int{0, 2}, owned)
+	owned = tx.OwnedOutputs(&sender.PrivateViewKey, &receiver.PublicSpendKey)
+	require.Len(owned, 0)
+
+	scanned := tx.ScanOwned(&sender)
+	require.Len(scanned, 2)
+	require.Equal(1, scanned[0].Index)
+	require.Equal(NewInteger(20), scanned[0].Amount)
+	require.Equal(tx.Outputs[1].Mask, scanned[0].Mask)
+	require.Equal(*tx.Outputs[1].Keys[0], scanned[0].PrivateKey.Public())
+	require.Equal(2, scanned[1].Index)
+	require.Equal(NewInteger(5), scanned[1].Amount)
+	require.Equal(*tx.Outputs[2].Keys[1], scanned[1].PrivateKey.Public())
+	view := sender.ViewOnly().Address()
+	require.Len(tx.ScanOwned(&view), 2)
+	require.NotEqual(*tx.Outputs[1].Keys[0], tx.ScanOwned(&view)[0].PrivateKey.Public())
+
+	seed := bytes.Repeat([]byte{7}, 64)
+	a, b := NewTransactionV5(XINAssetId), NewTransactionV5(XINAssetId)
+	a.AddScriptOutputWithRand([]*Address{&receiver}, script, NewInteger(1), bytes.NewReader(seed))
+	b.AddScriptOutputWithRand([]*Address{&receiver}, script, NewInteger(1), bytes.NewReader(seed))
+	require.Equal(a.AsVersioned().Marshal(), b.AsVersioned().Marshal())
+	require.Equal([]int{0}, a.OwnedOutputs(&receiver.PrivateViewKey, &receiver.PublicSpendKey))
+	require.Panics(func() {
+		a.AddScriptOutputWithRand([]*Address{&receiver}, script, NewInteger(1), bytes.NewReader(seed[:63]))
+	})
+}
+
+func TestCanonicalize(t *testing.T) {
+	require := require.New(t)
+
+	a, b := crypto.Blake3Hash([]byte("a")), crypto.Blake3Hash([]byte("b"))
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	tx := NewTransactionV5(XINAssetId)
+	tx.AddInput(b, 0)
+	tx.AddInput(a, 2)
+	tx.AddInput(a, 1)
+	tx.AddRandomScriptOutput([]*Address{}, NewThresholdScript(1), NewInteger(1))
+	outputs := slices.Clone(tx.Outputs)
+	before := tx.AsVersioned().PayloadHash()
+
+	tx.Canonicalize()
+	require.Equal(a, tx.Inputs[0].Hash)
+	require.Equal(uint(1), tx.Inputs[0].Index)
+	require.Equal(a, tx.Inputs[1].Hash)
+	require.Equal(uint(2), tx.Inputs[1].Index)
+	require.Equal(b, tx.Inputs[2].Hash)
+	require.Equal(outputs, tx.Outputs)
+	require.NotEqual(before, tx.AsVersioned().PayloadHash())
+}
+
+func TestTxBuilder(t *testing.T) {
+	require := require.New(t)
+
+	r := randomAccount()
+	b := NewTxBuilder(XINAssetId)
+	hash, err := b.CurrentHash()
+	require.Nil(err)
+	require.Equal(NewTransactionV5(XINAssetId).AsVersioned().PayloadHash(), hash)
+	for i := range 3 {
+		b.AddInput(crypto.Blake3Hash([]byte{byte(i)}), uint(i))
+		hash, err = b.CurrentHash()
+		require.Nil(err)
+		require.Equal(b.Transaction().AsVersioned().PayloadHash(), hash)
+		b.AddScriptOutput([]*Address{&r}, NewThresholdScript(1), NewInteger(uint64(i+1)), bytes.Repeat([]byte{byte(i)}, 64))
+		hash, err = b.CurrentHash()
+		require.Nil(err)
+		require.Equal(b.Transaction().AsVersioned().PayloadHash(), hash)
+	}
+	b.AddOutput(&Output{Type: OutputTypeScript, Amount: NewInteger(0), Script: NewThresholdScript(1), Keys: []*crypto.Key{}})
+	b.Transaction().References = []crypto.Hash{crypto.Blake3Hash([]byte("ref"))}
+	b.Transaction().Extra = []byte("extra")
+	hash, err = b.CurrentHash()
+	require.Nil(err)
+	require.Equal(b.Transaction().AsVersioned().PayloadHash(), hash)
+	ver, err := UnmarshalVersionedTransaction(b.Transaction().AsVersioned().Marshal())
+	require.Nil(err)
+	require.Equal(ver.PayloadHash(), hash)
+
+	b.Transaction().Extra = make([]byte, ExtraSizeStorageCapacity+1)
+	_, err = b.CurrentHash()
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid extra size")
+}
+
+func TestFeeExemptTransactionType(t *testing.T) {
+	require := require.New(t)
+
+	for tt, exempt := range map[uint8]bool{
+		TransactionTypeScript:               false,
+		TransactionTypeMint:                 true,
+		TransactionTypeDeposit:              true,
+		TransactionTypeWithdrawalSubmit:     false,
+		TransactionTypeWithdrawalClaim:      false,
+		TransactionTypeNodePledge:           true,
+		TransactionTypeNodeAccept:           true,
+		transactionTypeNodeResign:           true,
+		TransactionTypeNodeRemove:           true,
+		TransactionTypeNodeCancel:           true,
+		TransactionTypeCustodianUpdateNodes: true,
+		TransactionTypeCustodianSlashNodes:  true,
+		TransactionTypeUnknown:              false,
+		0x04:                                false,
+	} {
+		require.Equal(exempt, IsFeeExemptTransactionType(tt), tt)
+	}
+
+	require.Equal(0, StorageFee(ExtraSizeGeneralLimit).Sign())
+	require.Equal("0.00010000", StorageFee(ExtraSizeGeneralLimit+1).String())
+	require.Equal("0.00010000", StorageFee(ExtraSizeStorageStep).String())
+	require.Equal("0.00020000", StorageFee(ExtraSizeStorageStep+1).String())
+	require.Equal("0.40960000", StorageFee(ExtraSizeStorageCapacity).String())
+
+	tx := NewTransactionV5(XINAssetId)
+	tx.AddInput(crypto.Hash{}, 0)
+	tx.AddRandomScriptOutput([]*Address{}, NewThresholdScript(1), NewInteger(1))
+	require.Equal("0.00010000", tx.MinimumFee().String())
+	tx.Extra = make([]byte, ExtraSizeStorageStep*3)
+	require.Equal("0.00040000", tx.MinimumFee().String())
+	tx.Outputs[0].Type = OutputTypeWithdrawalClaim
+	require.Equal("0.00040000", tx.MinimumFee().String())
+	tx.Extra = nil
+	require.Equal("0.00010000", tx.MinimumFee().String())
+	tx.Outputs[0].Type = OutputTypeNodeCancel
+	tx.Extra = make([]byte, ExtraSizeStorageStep*3)
+	require.Equal(Zero, tx.MinimumFee())
+}
+
+func TestValidateWithdrawalClaim(t *testing.T) {
+	require := require.New(t)
+
+	submit := NewTransactionV5(BitcoinAssetId)
+	submit.AddInput(crypto.Blake3Hash([]byte("btc")), 0)
+	submit.Outputs = append(submit.Outputs, &Output{
+		Type:       OutputTypeWithdrawalSubmit,
+		Amount:     NewInteger(1),
+		Withdrawal: &WithdrawalData{Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+	})
+	signed := &SignedTransaction{Transaction: *submit}
+	fee := NewIntegerFromString("0.001")
+	require.Equal("1.00000000", submit.TotalWithdrawalAmount().String())
+
+	claim := NewTransactionV5(XINAssetId)
+	claim.AddInput(crypto.Blake3Hash([]byte("xin")), 0)
+	claim.Outputs = append(claim.Outputs, &Output{Type: OutputTypeWithdrawalClaim, Amount: fee})
+	require.Equal(0, claim.TotalWithdrawalAmount().Sign())
+	batch := NewTransactionV5(BitcoinAssetId)
+	batch.Outputs = append(batch.Outputs, submit.Outputs[0], claim.Outputs[0], submit.Outputs[0])
+	batch.Outputs = append(batch.Outputs, &Output{Type: OutputTypeWithdrawalSubmit, Amount: NewIntegerFromString("0.5")})
+	require.Equal("2.50000000", batch.TotalWithdrawalAmount().String())
+	claim.References = []crypto.Hash{signed.AsVersioned().PayloadHash()}
+	require.Nil(claim.ValidateWithdrawalClaim(signed, fee))
+
+	err := claim.ValidateWithdrawalClaim(signed, NewIntegerFromString("0.00001"))
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid withdrawal claim fee")
+	err = claim.ValidateWithdrawalClaim(signed, NewIntegerFromString("0.002"))
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid output amount")
+	claim.References = []crypto.Hash{claim.AsVersioned().PayloadHash()}
+	err = claim.ValidateWithdrawalClaim(signed, fee)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid withdrawal claim references")
+	claim.References = nil
+	err = claim.ValidateWithdrawalClaim(&SignedTransaction{Transaction: *claim}, fee)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid withdrawal submit data")
+
+	script := &SignedTransaction{Transaction: *NewTransactionV5(XINAssetId)}
+	txs := map[crypto.Hash]*SignedTransaction{
+		signed.AsVersioned().PayloadHash(): signed,
+		script.AsVersioned().PayloadHash(): script,
+	}
+	lookup := func(h crypto.Hash) (*SignedTransaction, error) { return txs[h], nil }
+	claim.References = []crypto.Hash{signed.AsVersioned().PayloadHash()}
+	require.Nil(claim.ValidateReferences(lookup))
+	require.Nil(submit.ValidateReferences(lookup))
+	claim.References = nil
+	err = claim.ValidateReferences(lookup)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid references count 0")
+	claim.References = []crypto.Hash{crypto.Blake3Hash([]byte("none"))}
+	err = claim.ValidateReferences(lookup)
+	require.NotNil(err)
+	require.Contains(err.Error(), "reference not found")
+	claim.References = []crypto.Hash{script.AsVersioned().PayloadHash()}
+	err = claim.ValidateReferences(lookup)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid withdrawal submit data")
+}
+
+func TestUpgradeToV5(t *testing.T) {
+	require := require.New(t)
+
+	tx := NewTransactionV5(XINAssetId)
+	tx.AddInput(crypto.Hash{}, 0)
+	tx.AddRandomScriptOutput([]*Address{}, NewThresholdScript(1), NewInteger(1))
+	hash := tx.AsVersioned().PayloadHash()
+	require.Nil(tx.UpgradeToV5())
+	require.Equal(hash, tx.AsVersioned().PayloadHash())
+
+	tx.Version = 0x04
+	require.Nil(tx.UpgradeToV5())
+	require.Equal(uint8(TxVersionHashSignature), tx.Version)
+	require.Equal(hash, tx.AsVersioned().PayloadHash())
+
+	tx.Version = 0x06
+	require.NotNil(tx.UpgradeToV5())
+	tx.Version = 0
+	require.NotNil(tx.UpgradeToV5())
+	tx.Version = 0x03
+	tx.Outputs[0].Type = outputTypeNodeResign
+	err := tx.UpgradeToV5()
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid output type 165")
+	require.Equal(uint8(0x03), tx.Version)
+
+	tx.Outputs[0].Type = OutputTypeScript
+	vh, err := tx.PayloadHashAsVersion(TxVersionHashSignature)
+	require.Nil(err)
+	require.Equal(hash, vh)
+	require.Equal(uint8(0x03), tx.Version)
+	_, err = tx.PayloadHashAsVersion(0x03)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid tx version 3")
+	_, err = tx.PayloadHashAsVersion(0x06)
+	require.NotNil(err)
+}
+
+func TestTransactionLimits(t *testing.T) {
+	require := require.New(t)
+	old := GetTransactionLimits()
+	t.Cleanup(func() { SetTransactionLimits(old) })
+
+	require.Equal(TransactionLimits{256, 16}, GetTransactionLimits())
+	require.NotNil(SetTransactionLimits(TransactionLimits{0, 0}))
+	require.NotNil(SetTransactionLimits(TransactionLimits{4, 8}))
+	require.Equal(DefaultTransactionLimits(), GetTransactionLimits())
+
+	tx := NewTransactionV5(XINAssetId)
+	tx.AddInput(crypto.Hash{}, 0)
+	tx.AddInput(crypto.Hash{}, 1)
+	tx.AddRandomScriptOutput([]*Address{}, NewThresholdScript(1), NewInteger(1))
+	tx.References = []crypto.Hash{{1}, {2}}
+	tx.Version = 0x04
+	require.Nil(tx.UpgradeToV5())
+
+	require.Nil(SetTransactionLimits(TransactionLimits{4, 1}))
+	tx.Version = 0x04
+	err := tx.UpgradeToV5()
+	require.NotNil(err)
+	require.Contains(err.Error(), "too many references 2")
+	require.Nil(SetTransactionLimits(TransactionLimits{1, 1}))
+	tx.References = nil
+	require.NotNil(tx.UpgradeToV5())
+	require.Equal(uint8(0x04), tx.Version)
+	var utxos []*UTXO
+	for i := range 2 {
+		utxos = append(utxos, &UTXO{Asset: XINAssetId, Output: Output{Amount: NewInteger(1)}})
+		utxos[i].Index = uint(i)
+	}
+	_, _, err = SelectUTXOs(utxos, NewInteger(2), SliceCountLimit)
+	require.NotNil(err)
+
+	require.Nil(SetTransactionLimits(DefaultTransactionLimits()))
+	require.Nil(tx.UpgradeToV5())
+
+	require.Nil(tx.CheckResourceLimits())
+	require.Nil(SetTransactionLimits(TransactionLimits{1, 1}))
+	err = tx.CheckResourceLimits()
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid tx inputs or outputs 2 1")
+	require.Nil(SetTransactionLimits(DefaultTransactionLimits()))
+	tx.References = make([]crypto.Hash, ReferencesCountLimit+1)
+	err = tx.CheckResourceLimits()
+	require.NotNil(err)
+	require.Contains(err.Error(), "too many references 17")
+	tx.References = nil
+	tx.Extra = make([]byte, ExtraSizeStorageCapacity+1)
+	err = tx.CheckResourceLimits()
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid extra size")
+	tx.Extra = make([]byte, ExtraSizeStorageCapacity)
+	err = tx.CheckResourceLimits()
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid transaction size")
 }
 
 type storeImpl struct {
@@ -244,8 +652,11 @@ func (store storeImpl) ReadUTXOKeys(hash crypto.Hash, index uint) (*UTXOKeys, er
 		return nil, err
 	}
 	return &UTXOKeys{
-		Mask: utxo.Mask,
-		Keys: utxo.Keys,
+		Mask:   utxo.Mask,
+		Keys:   utxo.Keys,
+		Script: utxo.Script,
+		Asset:  utxo.Asset,
+		Amount: utxo.Amount,
 	}, nil
 }
 
@@ -320,6 +731,66 @@ func randomAccount() Address {
 	return NewAddressFromSeed(seed)
 }
 
+func BenchmarkViewGhostKey(b *testing.B) {
+	accounts := []*Address{}
+	for i := 0; i < 3; i++ {
+		a := randomAccount()
+		accounts = append(accounts, &a)
+	}
+	seed := bytes.Repeat([]byte{1}, 64)
+	tx := NewTransactionV5(XINAssetId)
+	for i := 0; i < 256; i++ {
+		tx.AddScriptOutput(accounts, NewThresholdScript(2), NewInteger(1), seed)
+	}
+	a := &accounts[0].PrivateViewKey
+
+	b.Run("separate", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for i, o := range tx.Outputs {
+				for _, k := range o.Keys {
+					crypto.ViewGhostOutputKey(k, a, &o.Mask, uint64(i))
+				}
+			}
+		}
+	})
+	b.Run("shared", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			tx.ViewGhostKey(a)
+		}
+	})
+}
+
+func BenchmarkPayloadHash(b *testing.B) {
+	accounts := []*Address{}
+	for i := 0; i < 3; i++ {
+		a := randomAccount()
+		accounts = append(accounts, &a)
+	}
+	seed := bytes.Repeat([]byte{1}, 64)
+	for _, size := range []int{1, 16, 200} {
+		tx := NewTransactionV5(XINAssetId)
+		for i := 0; i < size; i++ {
+			tx.AddInput(crypto.Blake3Hash([]byte{byte(i)}), uint(i))
+			tx.AddScriptOutput(accounts, NewThresholdScript(2), NewInteger(1), seed)
+		}
+		ver := tx.AsVersioned()
+
+		b.Run(fmt.Sprintf("encode-%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				ver.resetCache()
+				ver.PayloadHash()
+			}
+		})
+		b.Run(fmt.Sprintf("cached-%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				ver.PayloadHash()
+			}
+		})
+	}
+}
+
 func (ver *VersionedTransaction) resetCache() {
 	ver.hash = crypto.Hash{}
 	ver.pmbytes = nil
@@ -1,7 +1,9 @@
 package common
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 
 	"github.com/MixinNetwork/mixin/crypto"
 )
@@ -17,9 +19,45 @@ type UTXOWithLock struct {
 	LockHash crypto.Hash
 }
 
+// UTXOKeys is the single read of an input for both signing and balance, the
+// readers fill the amount and asset along with the keys.
 type UTXOKeys struct {
-	Mask crypto.Key
-	Keys []*crypto.Key
+	Mask   crypto.Key
+	Keys   []*crypto.Key
+	Script Script
+	Asset  crypto.Hash
+	Amount Integer
+}
+
+// Validate checks a UTXO read from an untrusted source is well formed, it can't
+// tell whether the keys belong to the mask without the private view keys.
+func (u *UTXO) Validate() error {
+	if u.Index >= uint(GetTransactionLimits().SliceCount) {
+		return fmt.Errorf("invalid utxo index %d", u.Index)
+	}
+	if u.Amount.Sign() <= 0 || u.Amount.Cmp(MaximumSupply) > 0 {
+		return fmt.Errorf("invalid utxo amount %s", u.Amount)
+	}
+	err := u.Script.VerifyFormat()
+	if err != nil {
+		return err
+	}
+	return validateGhostKeys(&u.Mask, u.Keys)
+}
+
+func validateGhostKeys(mask *crypto.Key, keys []*crypto.Key) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("invalid utxo keys count %d", len(keys))
+	}
+	if !mask.HasValue() || !mask.CheckKey() {
+		return fmt.Errorf("invalid utxo mask %s", mask)
+	}
+	for i, k := range keys {
+		if k == nil || !k.CheckKey() {
+			return fmt.Errorf("invalid utxo key %d", i)
+		}
+	}
+	return nil
 }
 
 func (tx *VersionedTransaction) UnspentOutputs() []*UTXOWithLock {
@@ -100,3 +138,64 @@ func UnmarshalUTXO(b []byte) (*UTXOWithLock, error) {
 	err = dec.Read(utxo.LockHash[:])
 	return utxo, err
 }
+
+// SelectUTXOs sorts the utxos by amount in descending order, and accumulates
+// them until the target is covered, so that the fewest inputs are used. Ties
+// are broken by hash and index to make the selection deterministic.
+func SelectUTXOs(utxos []*UTXO, target Integer, maxInputs int) ([]*UTXO, Integer, error) {
+	if target.Sign() <= 0 {
+		return nil, Zero, fmt.Errorf("invalid selection target %s", target)
+	}
+	if maxInputs < 1 {
+		return nil, Zero, fmt.Errorf("invalid selection inputs limit %d", maxInputs)
+	}
+	if limit := GetTransactionLimits().SliceCount; maxInputs > limit {
+		maxInputs = limit
+	}
+
+	sorted := make([]*UTXO, 0, len(utxos))
+	for _, u := range utxos {
+		if u.Amount.Sign() > 0 {
+			sorted = append(sorted, u)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if c := a.Amount.Cmp(b.Amount); c != 0 {
+			return c > 0
+		}
+		if c := bytes.Compare(a.Hash[:], b.Hash[:]); c != 0 {
+			return c < 0
+		}
+		return a.Index < b.Index
+	})
+
+	total := NewInteger(0)
+	for i, u := range sorted {
+		if i == maxInputs {
+			break
+		}
+		total = total.Add(u.Amount)
+		if total.Cmp(target) >= 0 {
+			return sorted[:i+1], total.Sub(target), nil
+		}
+	}
+	return nil, Zero, fmt.Errorf("insufficient utxos %s %s within %d inputs", total, target, maxInputs)
+}
+
+// DistinctAssets returns the unique assets of the utxos sorted by bytes.
+func DistinctAssets(utxos []*UTXO) []crypto.Hash {
+	filter := make(map[crypto.Hash]bool)
+	assets := make([]crypto.Hash, 0)
+	for _, u := range utxos {
+		if filter[u.Asset] {
+			continue
+		}
+		filter[u.Asset] = true
+		assets = append(assets, u.Asset)
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		return bytes.Compare(assets[i][:], assets[j][:]) < 0
+	})
+	return assets
+}
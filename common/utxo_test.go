@@ -1,6 +1,7 @@
 package common
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/MixinNetwork/mixin/crypto"
@@ -33,4 +34,236 @@ func TestUTXO(t *testing.T) {
 	require.Equal("fffe02", utxo.Output.Script.String())
 	require.Len(utxo.Output.Keys, 3)
 	require.Equal(XINAssetId, utxo.Asset)
+
+	u := utxo.UTXO
+	require.Nil(u.Validate())
+	u.Index = SliceCountLimit
+	require.Contains(u.Validate().Error(), "invalid utxo index")
+	u.Index = 0
+	u.Amount = NewInteger(0)
+	require.Contains(u.Validate().Error(), "invalid utxo amount")
+	u.Amount = utxo.Amount
+	u.Script = Script{OperatorCmp, OperatorSum}
+	require.Contains(u.Validate().Error(), "invalid script length")
+	u.Script = script
+	u.Keys = nil
+	require.Contains(u.Validate().Error(), "invalid utxo keys count")
+	u.Keys = []*crypto.Key{utxo.Keys[0], {0xff, 0xff}}
+	require.Contains(u.Validate().Error(), "invalid utxo key 1")
+	u.Keys = utxo.Keys
+	u.Mask = crypto.Key{}
+	require.Contains(u.Validate().Error(), "invalid utxo mask")
+
+	spend := NewTransactionV5(XINAssetId).AsVersioned()
+	spend.AddInput(u.Hash, u.Index)
+	err := spend.SignUTXO(&u, accounts[:1])
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid utxo mask")
+	require.Nil(spend.SignUTXO(&utxo.UTXO, accounts[:2]))
+
+	private := []*Address{{
+		PrivateViewKey:  accounts[0].PrivateViewKey,
+		PrivateSpendKey: accounts[0].PrivateSpendKey,
+	}}
+	require.Nil(spend.SignUTXO(&utxo.UTXO, private))
+	require.Equal(spend.SignaturesMap[0][0], spend.SignaturesMap[1][0])
+	watch := accounts[0].ViewOnly().Address()
+	err = spend.SignUTXO(&utxo.UTXO, []*Address{&watch})
+	require.NotNil(err)
+	require.Contains(err.Error(), "watch-only")
+}
+
+func TestSelectUTXOs(t *testing.T) {
+	require := require.New(t)
+
+	var utxos []*UTXO
+	for i, a := range []uint64{3, 10, 1, 10, 5} {
+		u := &UTXO{Asset: XINAssetId}
+		u.Hash = crypto.Blake3Hash([]byte{byte(i)})
+		u.Amount = NewInteger(a)
+		utxos = append(utxos, u)
+	}
+
+	selected, change, err := SelectUTXOs(utxos, NewInteger(12), 4)
+	require.Nil(err)
+	require.Len(selected, 2)
+	require.Equal("10.00000000", selected[0].Amount.String())
+	require.Equal("10.00000000", selected[1].Amount.String())
+	require.Equal("8.00000000", change.String())
+	again, _, _ := SelectUTXOs(utxos, NewInteger(12), 4)
+	require.Equal(selected, again)
+
+	selected, change, err = SelectUTXOs(utxos, NewInteger(25), 3)
+	require.Nil(err)
+	require.Len(selected, 3)
+	require.Equal("0.00000000", change.String())
+
+	_, _, err = SelectUTXOs(utxos, NewInteger(26), 3)
+	require.NotNil(err)
+	require.Contains(err.Error(), "insufficient utxos")
+	_, _, err = SelectUTXOs(utxos, NewInteger(30), 10)
+	require.NotNil(err)
+	_, _, err = SelectUTXOs(utxos, NewInteger(1), 0)
+	require.NotNil(err)
+	_, _, err = SelectUTXOs(utxos, NewInteger(0), 1)
+	require.NotNil(err)
+}
+
+func TestDistinctAssets(t *testing.T) {
+	require := require.New(t)
+
+	require.Len(DistinctAssets(nil), 0)
+	var utxos []*UTXO
+	for _, a := range []crypto.Hash{XINAssetId, BitcoinAssetId, XINAssetId, EthereumAssetId, BitcoinAssetId} {
+		utxos = append(utxos, &UTXO{Asset: a})
+	}
+	assets := DistinctAssets(utxos)
+	require.Len(assets, 3)
+	require.ElementsMatch([]crypto.Hash{XINAssetId, BitcoinAssetId, EthereumAssetId}, assets)
+	for i := 1; i < len(assets); i++ {
+		require.True(bytes.Compare(assets[i-1][:], assets[i][:]) < 0)
+	}
+}
+
+func TestBuildSpend(t *testing.T) {
+	require := require.New(t)
+
+	sender, receiver := randomAccount(), randomAccount()
+	var utxos []*UTXO
+	for i := 0; i < 3; i++ {
+		u := &UTXO{Asset: XINAssetId}
+		u.Hash = crypto.Blake3Hash([]byte{byte(i)})
+		u.Amount = NewInteger(uint64(i + 1))
+		utxos = append(utxos, u)
+	}
+
+	signed, err := BuildSpend(XINAssetId, utxos, []*Address{&receiver}, NewInteger(4), []*Address{&sender})
+	require.Nil(err)
+	require.Len(signed.Inputs, 2)
+	require.Len(signed.Outputs, 2)
+	require.Equal("4.00000000", signed.Outputs[0].Amount.String())
+	require.Equal("1.00000000", signed.Outputs[1].Amount.String())
+	require.Equal([]int{0}, signed.OwnedOutputs(&receiver.PrivateViewKey, &receiver.PublicSpendKey))
+	require.Equal([]int{1}, signed.OwnedOutputs(&sender.PrivateViewKey, &sender.PublicSpendKey))
+
+	signed, err = BuildSpend(XINAssetId, utxos, []*Address{&receiver}, NewInteger(6), nil)
+	require.Nil(err)
+	require.Len(signed.Inputs, 3)
+	require.Len(signed.Outputs, 1)
+
+	_, err = BuildSpend(XINAssetId, utxos, []*Address{&receiver}, NewIntegerFromString("4.5"), nil)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid change accounts")
+	_, err = BuildSpend(crypto.Blake3Hash([]byte("asset")), utxos, []*Address{&receiver}, NewInteger(5), nil)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid utxo asset")
+}
+
+func TestBuildAndSign(t *testing.T) {
+	require := require.New(t)
+
+	sender, other, receiver := randomAccount(), randomAccount(), randomAccount()
+	source := NewTransactionV5(XINAssetId)
+	source.AddRandomScriptOutput([]*Address{&sender}, NewThresholdScript(1), NewInteger(3))
+	source.AddRandomScriptOutput([]*Address{&sender, &other}, NewThresholdScript(2), NewInteger(2))
+	source.AddRandomScriptOutput([]*Address{&other}, NewThresholdScript(1), NewInteger(1))
+	hash := source.AsVersioned().PayloadHash()
+	var utxos []*UTXO
+	for i, o := range source.Outputs {
+		u := &UTXO{Asset: XINAssetId, Output: *o}
+		u.Hash, u.Index = hash, uint(i)
+		utxos = append(utxos, u)
+	}
+
+	accounts := [][]*Address{{&sender}, {&other, &sender}, {&other}}
+	signed, err := BuildAndSign(XINAssetId, utxos, []*Address{&receiver}, NewInteger(4), []*Address{&sender}, accounts)
+	require.Nil(err)
+	require.Len(signed.Inputs, 2)
+	require.Len(signed.SignaturesMap, 2)
+	require.Len(signed.SignaturesMap[1], 2)
+	complete, err := signed.IsComplete(utxoSliceReader(utxos))
+	require.Nil(err)
+	require.True(complete)
+	err = signed.RetargetOutput(1, []*Address{&other}, make([]byte, 64))
+	require.NotNil(err)
+	require.Contains(err.Error(), "signed transaction")
+
+	unsigned, err := BuildSpend(XINAssetId, utxos, []*Address{&receiver}, NewInteger(4), []*Address{&sender})
+	require.Nil(err)
+	require.Equal([]int{1}, unsigned.OwnedOutputs(&sender.PrivateViewKey, &sender.PublicSpendKey))
+	require.Nil(unsigned.RetargetOutput(1, []*Address{&other}, make([]byte, 64)))
+	require.Len(unsigned.OwnedOutputs(&sender.PrivateViewKey, &sender.PublicSpendKey), 0)
+	require.Equal([]int{1}, unsigned.OwnedOutputs(&other.PrivateViewKey, &other.PublicSpendKey))
+	require.Equal("1.00000000", unsigned.Outputs[1].Amount.String())
+	require.NotNil(unsigned.RetargetOutput(2, []*Address{&other}, make([]byte, 64)))
+	require.NotNil(unsigned.RetargetOutput(1, nil, make([]byte, 64)))
+
+	coverable, err := unsigned.CoverableInputs(utxoSliceReader(utxos), []*Address{&sender})
+	require.Nil(err)
+	require.Equal([]int{0}, coverable)
+	coverable, err = unsigned.CoverableInputs(utxoSliceReader(utxos), []*Address{&other, &sender, &other})
+	require.Nil(err)
+	require.Equal([]int{0, 1}, coverable)
+	coverable, err = unsigned.CoverableInputs(utxoSliceReader(utxos), []*Address{&receiver})
+	require.Nil(err)
+	require.Len(coverable, 0)
+	_, err = unsigned.CoverableInputs(utxoSliceReader(utxos[2:]), []*Address{&sender})
+	require.NotNil(err)
+
+	rb, err := unsigned.SigningRequest(utxoSliceReader(utxos))
+	require.Nil(err)
+	_, err = ParseSigningRequest(append(rb, 0))
+	require.NotNil(err)
+	_, err = ParseSigningRequest(rb[:len(rb)-1])
+	require.NotNil(err)
+	req, err := ParseSigningRequest(rb)
+	require.Nil(err)
+	require.Equal(unsigned.AsVersioned().PayloadHash(), req.Transaction.PayloadHash())
+	require.Len(req.Inputs, 2)
+	require.Equal(utxos[1].Keys, req.Inputs[1].Keys)
+	require.Equal(utxos[1].Mask, req.Inputs[1].Mask)
+	require.Equal(utxos[1].Amount, req.Inputs[1].Amount)
+	for i := range req.Transaction.Inputs {
+		err = req.Transaction.SignInput(req, i, accounts[i])
+		require.Nil(err)
+	}
+	complete, err = req.Transaction.IsComplete(utxoSliceReader(utxos))
+	require.Nil(err)
+	require.True(complete)
+	bundle := req.Transaction.SignatureBundle()
+	forged := req.Transaction.SignatureBundle()
+	forged[len(forged)-1] ^= 1
+	err = unsigned.ApplySignatureBundle(req, forged)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid signature")
+	require.Nil(unsigned.SignaturesMap)
+	err = unsigned.ApplySignatureBundle(utxoSliceReader(utxos[2:]), bundle)
+	require.NotNil(err)
+	require.Contains(err.Error(), "input not found")
+	require.Nil(unsigned.ApplySignatureBundle(req, bundle))
+	require.Equal(req.Transaction.SignaturesMap, unsigned.SignaturesMap)
+	require.Nil(unsigned.ApplySignatureBundle(utxoSliceReader(utxos), bundle))
+	conflict := req.Transaction.SignaturesMap[0][0]
+	req.Transaction.SignaturesMap[0][0] = unsigned.SignaturesMap[1][0]
+	err = req.Transaction.ApplySignatureBundle(req, unsigned.SignatureBundle())
+	require.NotNil(err)
+	require.Contains(err.Error(), "conflict signature")
+	req.Transaction.SignaturesMap[0][0] = conflict
+	copy(bundle[4:], make([]byte, 32))
+	require.NotNil(unsigned.ApplySignatureBundle(req, bundle))
+	_, err = unsigned.SigningRequest(utxoSliceReader(utxos[2:]))
+	require.NotNil(err)
+
+	accounts[1] = []*Address{&sender}
+	signed, err = BuildAndSign(XINAssetId, utxos, []*Address{&receiver}, NewInteger(4), []*Address{&sender}, accounts)
+	require.NotNil(err)
+	require.Contains(err.Error(), "insufficient signatures")
+	require.Nil(signed)
+	accounts[1] = []*Address{&receiver}
+	_, err = BuildAndSign(XINAssetId, utxos, []*Address{&receiver}, NewInteger(4), []*Address{&sender}, accounts)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid key for the input")
+	_, err = BuildAndSign(XINAssetId, utxos, []*Address{&receiver}, NewInteger(4), []*Address{&sender}, accounts[:2])
+	require.NotNil(err)
+	require.Len(utxos[1].Keys, 2)
 }
@@ -24,8 +24,9 @@ func (ver *VersionedTransaction) Validate(store DataStore, snapTime uint64, fork
 		return fmt.Errorf("invalid tx inputs or outputs %d %d",
 			len(tx.Inputs), len(tx.Outputs))
 	}
-	if len(tx.Inputs) > SliceCountLimit || len(tx.Outputs) > SliceCountLimit ||
-		len(tx.References) > SliceCountLimit {
+	limits := GetTransactionLimits()
+	if len(tx.Inputs) > limits.SliceCount || len(tx.Outputs) > limits.SliceCount ||
+		len(tx.References) > limits.SliceCount {
 		return fmt.Errorf("invalid tx inputs or outputs %d %d %d",
 			len(tx.Inputs), len(tx.Outputs), len(tx.References))
 	}
@@ -48,6 +49,10 @@ func (ver *VersionedTransaction) Validate(store DataStore, snapTime uint64, fork
 		}
 	}
 
+	if h, i, dup := tx.HasDuplicateInputs(); dup {
+		return fmt.Errorf("invalid input %s:%d", h, i)
+	}
+
 	err := validateReferences(store, tx)
 	if err != nil {
 		return err
@@ -121,6 +126,75 @@ func (tx *SignedTransaction) GetExtraLimit() int {
 	return int(limit)
 }
 
+// StorageFee is the XIN amount of the storage output required by an extra of
+// size bytes, each ExtraSizeStorageStep bytes cost ExtraStoragePriceStep.
+func StorageFee(size int) Integer {
+	if size <= ExtraSizeGeneralLimit {
+		return Zero
+	}
+	cells := (size + ExtraSizeStorageStep - 1) / ExtraSizeStorageStep
+	return NewIntegerFromString(ExtraStoragePriceStep).Mul(cells)
+}
+
+// MinimumFee is the XIN required to be accepted, the TransactionBaseFee and
+// the storage of the extra, it's zero for IsFeeExemptTransactionType.
+func (tx *Transaction) MinimumFee() Integer {
+	signed := &SignedTransaction{Transaction: *tx}
+	if IsFeeExemptTransactionType(signed.TransactionType()) {
+		return Zero
+	}
+	return StorageFee(len(tx.Extra)).Add(NewIntegerFromString(TransactionBaseFee))
+}
+
+// ImpliedFee returns the amount burned to pay for the extra storage, inputs
+// and outputs must balance exactly because no fee is implied by the balance.
+func (tx *SignedTransaction) ImpliedFee(reader UTXOKeysReader) (Integer, error) {
+	inputAmount := NewInteger(0)
+	for _, in := range tx.Inputs {
+		var amount Integer
+		switch {
+		case in.Deposit != nil:
+			amount = in.Deposit.Amount
+		case in.Mint != nil:
+			amount = in.Mint.Amount
+		case in.Genesis != nil:
+			return Zero, fmt.Errorf("invalid genesis input %s:%d", in.Hash.String(), in.Index)
+		default:
+			utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
+			if err != nil {
+				return Zero, err
+			}
+			if utxo == nil {
+				return Zero, fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
+			}
+			amount = utxo.Amount
+		}
+		if amount.Sign() <= 0 {
+			return Zero, fmt.Errorf("invalid input amount %s", amount)
+		}
+		inputAmount = inputAmount.Add(amount)
+	}
+
+	outputAmount := NewInteger(0)
+	for _, o := range tx.Outputs {
+		if o.Amount.Sign() > 0 {
+			outputAmount = outputAmount.Add(o.Amount)
+		}
+	}
+	if inputAmount.Cmp(outputAmount) != 0 {
+		return Zero, fmt.Errorf("invalid input output amount %s %s", inputAmount, outputAmount)
+	}
+
+	if tx.Asset != XINAssetId {
+		return Zero, nil
+	}
+	out := tx.findStorageOutput()
+	if out == nil {
+		return Zero, nil
+	}
+	return out.Amount, nil
+}
+
 func (tx *SignedTransaction) findStorageOutput() *Output {
 	var so *Output
 	for _, out := range tx.Outputs {
@@ -150,7 +224,7 @@ func validateScriptTransaction(inputs map[string]*UTXO) error {
 }
 
 func validateReferences(store TransactionReader, tx *SignedTransaction) error {
-	if len(tx.References) > ReferencesCountLimit {
+	if len(tx.References) > GetTransactionLimits().ReferencesCount {
 		return fmt.Errorf("too many references %d", len(tx.References))
 	}
 
@@ -167,6 +241,43 @@ func validateReferences(store TransactionReader, tx *SignedTransaction) error {
 	return nil
 }
 
+// ValidateReferences resolves the references with lookup and checks them by
+// the transaction type, a withdrawal claim must reference its sole submit.
+func (tx *Transaction) ValidateReferences(lookup func(crypto.Hash) (*SignedTransaction, error)) error {
+	if len(tx.References) > GetTransactionLimits().ReferencesCount {
+		return fmt.Errorf("too many references %d", len(tx.References))
+	}
+
+	refs := make([]*SignedTransaction, len(tx.References))
+	for i, r := range tx.References {
+		rtx, err := lookup(r)
+		if err != nil {
+			return err
+		}
+		if rtx == nil {
+			return fmt.Errorf("reference not found %s", r)
+		}
+		if rtx.Version != TxVersionHashSignature {
+			return fmt.Errorf("invalid reference %s version %d", r, rtx.Version)
+		}
+		refs[i] = rtx
+	}
+
+	signed := &SignedTransaction{Transaction: *tx}
+	switch signed.TransactionType() {
+	case TransactionTypeWithdrawalClaim:
+		if len(refs) != 1 {
+			return fmt.Errorf("invalid references count %d for withdrawal claim transaction", len(refs))
+		}
+		submit := refs[0]
+		if len(submit.Outputs) < 1 || submit.Outputs[0].Type != OutputTypeWithdrawalSubmit ||
+			submit.Outputs[0].Withdrawal == nil {
+			return fmt.Errorf("invalid withdrawal submit data")
+		}
+	}
+	return nil
+}
+
 func (tx *SignedTransaction) validateInputs(store UTXOLockReader, hash crypto.Hash, txType uint8, fork bool) (map[string]*UTXO, Integer, error) {
 	inputAmount := NewInteger(0)
 	inputsFilter := make(map[string]*UTXO)
@@ -249,8 +360,9 @@ func (tx *Transaction) validateOutputs(store GhostLocker, hash crypto.Hash, inpu
 	outputAmount := NewInteger(0)
 	ghostKeysFilter := make(map[crypto.Key]bool)
 	ghostKeys := make([]*crypto.Key, 0)
+	limits := GetTransactionLimits()
 	for _, o := range tx.Outputs {
-		if len(o.Keys) > SliceCountLimit {
+		if len(o.Keys) > limits.SliceCount {
 			return fmt.Errorf("invalid output keys count %d", len(o.Keys))
 		}
 		if o.Amount.Sign() <= 0 {
@@ -2,8 +2,11 @@ package common
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"io"
 
 	"github.com/MixinNetwork/mixin/config"
 	"github.com/MixinNetwork/mixin/crypto"
@@ -34,6 +37,45 @@ func (tx *Transaction) AsVersioned() *VersionedTransaction {
 	}
 }
 
+// PayloadHashAsVersion hashes the payload encoded as version v, regardless
+// of the Version field of tx, only the versions with an encoding are valid.
+func (tx *Transaction) PayloadHashAsVersion(v uint8) (crypto.Hash, error) {
+	switch v {
+	case TxVersionHashSignature:
+	default:
+		return crypto.Hash{}, fmt.Errorf("invalid tx version %d", v)
+	}
+	ver := &VersionedTransaction{SignedTransaction: SignedTransaction{Transaction: *tx}}
+	ver.Version = v
+	return crypto.Blake3Hash(ver.payloadMarshal()), nil
+}
+
+// UpgradeToV5 migrates a legacy transaction to the v5 layout, the fields are
+// the same in memory, only the version changes, so the payload hash and all
+// signatures of the legacy transaction are no longer valid after the upgrade.
+func (tx *Transaction) UpgradeToV5() error {
+	if tx.Version == TxVersionHashSignature {
+		return nil
+	}
+	if tx.Version == 0 || tx.Version > TxVersionHashSignature {
+		return fmt.Errorf("invalid transaction version %d", tx.Version)
+	}
+	for i, out := range tx.Outputs {
+		if out.Type == outputTypeNodeResign {
+			return fmt.Errorf("invalid output type %d at %d for v5", out.Type, i)
+		}
+	}
+	limits := GetTransactionLimits()
+	if len(tx.Inputs) > limits.SliceCount || len(tx.Outputs) > limits.SliceCount {
+		return fmt.Errorf("invalid tx inputs or outputs %d %d", len(tx.Inputs), len(tx.Outputs))
+	}
+	if len(tx.References) > limits.ReferencesCount {
+		return fmt.Errorf("too many references %d", len(tx.References))
+	}
+	tx.Version = TxVersionHashSignature
+	return nil
+}
+
 func UnmarshalVersionedTransaction(val []byte) (*VersionedTransaction, error) {
 	return unmarshalVersionedTransaction(val)
 }
@@ -79,6 +121,287 @@ func (ver *VersionedTransaction) PayloadHash() crypto.Hash {
 	return ver.hash
 }
 
+// Hash covers the complete encoding including all signatures, so it changes
+// whenever signatures are added or replaced. Transactions are referenced and
+// locked by PayloadHash, which excludes the signatures, and Hash should only
+// be used to tell apart different signed copies of the same transaction.
+func (signed *SignedTransaction) Hash() crypto.Hash {
+	return crypto.Blake3Hash(signed.AsVersioned().Marshal())
+}
+
+// IsCanonical reports whether raw decodes to signed and is exactly the bytes
+// it re-encodes to, e.g. integers with leading zero bytes decode to the same
+// transaction but are not canonical and should be rejected.
+func (signed *SignedTransaction) IsCanonical(raw []byte) bool {
+	ver, err := unmarshalVersionedTransaction(raw)
+	if err != nil {
+		return false
+	}
+	if !bytes.Equal(ver.marshal(), raw) {
+		return false
+	}
+	return bytes.Equal(signed.AsVersioned().marshal(), raw)
+}
+
+// EncodeURL encodes the transaction as base64url without padding, for URLs and QR codes.
+func (signed *SignedTransaction) EncodeURL() string {
+	return base64.RawURLEncoding.EncodeToString(signed.AsVersioned().Marshal())
+}
+
+func DecodeSignedTransactionURL(s string) (*SignedTransaction, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return SafeDecodeSignedTransaction(b)
+}
+
+// EncodeTo writes the transaction as a record of the uint32 big endian size
+// and the encoded transaction, and returns the bytes written.
+func (signed *SignedTransaction) EncodeTo(w io.Writer) (int, error) {
+	val := signed.AsVersioned().Marshal()
+	size := binary.BigEndian.AppendUint32(nil, uint32(len(val)))
+	n, err := w.Write(size)
+	if err != nil {
+		return n, err
+	}
+	m, err := w.Write(val)
+	return n + m, err
+}
+
+// DecodeSignedTransactionFrom reads a record written by EncodeTo, it returns
+// io.EOF only if there is no more record to read.
+func DecodeSignedTransactionFrom(r io.Reader) (*SignedTransaction, error) {
+	size := make([]byte, 4)
+	_, err := io.ReadFull(r, size)
+	if err != nil {
+		return nil, err
+	}
+	l := binary.BigEndian.Uint32(size)
+	if l > config.TransactionMaximumSize {
+		return nil, fmt.Errorf("transaction too large %d", l)
+	}
+	val := make([]byte, l)
+	_, err = io.ReadFull(r, val)
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	return SafeDecodeSignedTransaction(val)
+}
+
+// SigningRequest bundles the transaction with the UTXO keys of its inputs, so
+// an offline signer could run SignInput with the request as the reader.
+type SigningRequest struct {
+	Transaction *VersionedTransaction
+	Inputs      []*UTXOKeys
+}
+
+func (signed *SignedTransaction) SigningRequest(reader UTXOKeysReader) ([]byte, error) {
+	val := signed.AsVersioned().Marshal()
+	if len(val) > config.TransactionMaximumSize {
+		return nil, fmt.Errorf("transaction too large %d", len(val))
+	}
+	enc := NewMinimumEncoder()
+	enc.WriteUint32(uint32(len(val)))
+	enc.Write(val)
+	for _, in := range signed.Inputs {
+		if in.Deposit != nil || in.Mint != nil {
+			enc.Write(null)
+			continue
+		}
+		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
+		if err != nil {
+			return nil, err
+		}
+		if utxo == nil {
+			return nil, fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
+		}
+		enc.Write(magic)
+		enc.Write(utxo.Mask[:])
+		enc.WriteInt(len(utxo.Keys))
+		for _, k := range utxo.Keys {
+			enc.Write(k[:])
+		}
+		enc.WriteInt(len(utxo.Script))
+		enc.Write(utxo.Script)
+		enc.Write(utxo.Asset[:])
+		enc.WriteInteger(utxo.Amount)
+	}
+	return enc.Bytes(), nil
+}
+
+func ParseSigningRequest(b []byte) (*SigningRequest, error) {
+	dec, err := NewMinimumDecoder(b)
+	if err != nil {
+		return nil, err
+	}
+	l, err := dec.ReadUint32()
+	if err != nil {
+		return nil, err
+	}
+	if l > config.TransactionMaximumSize {
+		return nil, fmt.Errorf("transaction too large %d", l)
+	}
+	val := make([]byte, l)
+	err = dec.Read(val)
+	if err != nil {
+		return nil, err
+	}
+	ver, err := UnmarshalVersionedTransaction(val)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &SigningRequest{Transaction: ver}
+	for range ver.Inputs {
+		hu, err := dec.ReadMagic()
+		if err != nil {
+			return nil, err
+		}
+		if !hu {
+			req.Inputs = append(req.Inputs, nil)
+			continue
+		}
+		utxo := &UTXOKeys{}
+		err = dec.Read(utxo.Mask[:])
+		if err != nil {
+			return nil, err
+		}
+		kc, err := dec.ReadInt()
+		if err != nil {
+			return nil, err
+		}
+		for range kc {
+			var k crypto.Key
+			err = dec.Read(k[:])
+			if err != nil {
+				return nil, err
+			}
+			utxo.Keys = append(utxo.Keys, &k)
+		}
+		utxo.Script, err = dec.ReadBytes()
+		if err != nil {
+			return nil, err
+		}
+		err = dec.Read(utxo.Asset[:])
+		if err != nil {
+			return nil, err
+		}
+		utxo.Amount, err = dec.ReadInteger()
+		if err != nil {
+			return nil, err
+		}
+		req.Inputs = append(req.Inputs, utxo)
+	}
+	if dec.buf.Len() != 0 {
+		return nil, fmt.Errorf("invalid signing request size %d", len(b))
+	}
+	return req, nil
+}
+
+func (req *SigningRequest) ReadUTXOKeys(hash crypto.Hash, index uint) (*UTXOKeys, error) {
+	for i, in := range req.Transaction.Inputs {
+		if in.Hash == hash && in.Index == index {
+			return req.Inputs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// SignatureBundle encodes the signatures map bound to the payload hash, for
+// the offline signer to return to ApplySignatureBundle.
+func (signed *SignedTransaction) SignatureBundle() []byte {
+	hash := signed.AsVersioned().PayloadHash()
+	enc := NewMinimumEncoder()
+	enc.Write(hash[:])
+	enc.WriteInt(len(signed.SignaturesMap))
+	for _, sm := range signed.SignaturesMap {
+		enc.EncodeSignatures(sm)
+	}
+	return enc.Bytes()
+}
+
+// ApplySignatureBundle verifies the signatures of a bundle made for the same
+// payload hash with the input keys of reader, then merges them.
+func (signed *SignedTransaction) ApplySignatureBundle(reader UTXOKeysReader, b []byte) error {
+	if signed.AggregatedSignature != nil {
+		return fmt.Errorf("invalid signatures with aggregated signature")
+	}
+	dec, err := NewMinimumDecoder(b)
+	if err != nil {
+		return err
+	}
+	var hash crypto.Hash
+	err = dec.Read(hash[:])
+	if err != nil {
+		return err
+	}
+	if payload := signed.AsVersioned().PayloadHash(); hash != payload {
+		return fmt.Errorf("invalid signature bundle hash %s %s", hash, payload)
+	}
+	sc, err := dec.ReadInt()
+	if err != nil {
+		return err
+	}
+	if sc > len(signed.Inputs) {
+		return fmt.Errorf("invalid signature bundle inputs %d %d", sc, len(signed.Inputs))
+	}
+	bundle := make([]map[uint16]*crypto.Signature, sc)
+	for i := range bundle {
+		bundle[i], err = dec.ReadSignatures()
+		if err != nil {
+			return err
+		}
+	}
+	if dec.buf.Len() != 0 {
+		return fmt.Errorf("invalid signature bundle size %d", len(b))
+	}
+
+	for i, sm := range bundle {
+		if len(sm) == 0 {
+			continue
+		}
+		in := signed.Inputs[i]
+		utxo, err := reader.ReadUTXOKeys(in.Hash, in.Index)
+		if err != nil {
+			return err
+		}
+		if utxo == nil {
+			return fmt.Errorf("input not found %s:%d", in.Hash.String(), in.Index)
+		}
+		for k, sig := range sm {
+			if int(k) >= len(utxo.Keys) || !utxo.Keys[k].Verify(hash, *sig) {
+				return fmt.Errorf("invalid signature %d for the input %d", k, i)
+			}
+		}
+	}
+	for i, sm := range bundle {
+		if i >= len(signed.SignaturesMap) {
+			break
+		}
+		for k, sig := range sm {
+			if old := signed.SignaturesMap[i][k]; old != nil && *old != *sig {
+				return fmt.Errorf("conflict signature %d for the input %d", k, i)
+			}
+		}
+	}
+	for len(signed.SignaturesMap) < len(bundle) {
+		signed.SignaturesMap = append(signed.SignaturesMap, make(map[uint16]*crypto.Signature))
+	}
+	for i, sm := range bundle {
+		if signed.SignaturesMap[i] == nil {
+			signed.SignaturesMap[i] = make(map[uint16]*crypto.Signature)
+		}
+		for k, sig := range sm {
+			signed.SignaturesMap[i][k] = sig
+		}
+	}
+	return nil
+}
+
 func checkTxVersion(val []byte) uint8 {
 	if len(val) < 4 {
 		return 0
@@ -135,7 +458,9 @@ func (ver *VersionedTransaction) payloadMarshal() []byte {
 	switch ver.Version {
 	case TxVersionHashSignature:
 		signed := &SignedTransaction{Transaction: ver.Transaction}
-		return NewEncoder().EncodeTransaction(signed)
+		return encodePooled(func(enc *Encoder) []byte {
+			return enc.EncodeTransaction(signed)
+		})
 	default:
 		panic(ver.Version)
 	}
@@ -12,6 +12,46 @@ type WithdrawalData struct {
 	Tag     string
 }
 
+func (w *WithdrawalData) Bytes() []byte {
+	enc := NewMinimumEncoder()
+	enc.WriteInt(len(w.Address))
+	enc.Write([]byte(w.Address))
+	enc.WriteInt(len(w.Tag))
+	enc.Write([]byte(w.Tag))
+	return enc.Bytes()
+}
+
+func ParseWithdrawalData(b []byte) (*WithdrawalData, error) {
+	dec, err := NewMinimumDecoder(b)
+	if err != nil {
+		return nil, err
+	}
+	ab, err := dec.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	tb, err := dec.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	if dec.buf.Len() != 0 {
+		return nil, fmt.Errorf("invalid withdrawal data size %d", len(b))
+	}
+	return &WithdrawalData{Address: string(ab), Tag: string(tb)}, nil
+}
+
+// TotalWithdrawalAmount sums the amounts of all the withdrawal submit outputs.
+func (tx *Transaction) TotalWithdrawalAmount() Integer {
+	total := NewInteger(0)
+	for _, o := range tx.Outputs {
+		if o.Type != OutputTypeWithdrawalSubmit || o.Amount.Sign() <= 0 {
+			continue
+		}
+		total = total.Add(o.Amount)
+	}
+	return total
+}
+
 func (tx *Transaction) validateWithdrawalSubmit(inputs map[string]*UTXO) error {
 	for _, in := range inputs {
 		if in.Type != OutputTypeScript {
@@ -45,6 +85,33 @@ func (tx *Transaction) validateWithdrawalSubmit(inputs map[string]*UTXO) error {
 	return nil
 }
 
+// ValidateWithdrawalClaim checks the claim against its submit before it's sent,
+// the claim pays fee in XIN, which is at least the WithdrawalClaimFee, and is
+// never deducted from the submit, which is usually in another asset.
+func (tx *Transaction) ValidateWithdrawalClaim(submit *SignedTransaction, fee Integer) error {
+	if fee.Cmp(NewIntegerFromString(config.WithdrawalClaimFee)) < 0 {
+		return fmt.Errorf("invalid withdrawal claim fee %s", fee)
+	}
+	if tx.Asset != XINAssetId {
+		return fmt.Errorf("invalid asset %s for withdrawal claim transaction", tx.Asset)
+	}
+	if len(tx.Outputs) < 1 || tx.Outputs[0].Type != OutputTypeWithdrawalClaim {
+		return fmt.Errorf("invalid withdrawal claim output")
+	}
+	if claim := tx.Outputs[0]; claim.Amount.Cmp(fee) != 0 {
+		return fmt.Errorf("invalid output amount %s for withdrawal claim fee %s", claim.Amount, fee)
+	}
+	if len(submit.Outputs) < 1 || submit.Outputs[0].Type != OutputTypeWithdrawalSubmit ||
+		submit.Outputs[0].Withdrawal == nil {
+		return fmt.Errorf("invalid withdrawal submit data")
+	}
+	hash := submit.AsVersioned().PayloadHash()
+	if len(tx.References) != 1 || tx.References[0] != hash {
+		return fmt.Errorf("invalid withdrawal claim references %v %s", tx.References, hash)
+	}
+	return nil
+}
+
 func (tx *Transaction) validateWithdrawalClaim(store DataStore, inputs map[string]*UTXO, snapTime uint64) error {
 	for _, in := range inputs {
 		if in.Type != OutputTypeScript {
@@ -23,6 +23,23 @@ func aggregatePublicKey(publics []*Key, signers []int) (*Key, error) {
 	return &key, nil
 }
 
+// AggregatePublicKeys returns the sum of all keys, the aggregate public key
+// A that an aggregated signature of these keys verifies against.
+func AggregatePublicKeys(keys []*Key) (Key, error) {
+	if len(keys) == 0 {
+		return Key{}, fmt.Errorf("invalid aggregation keys count %d", len(keys))
+	}
+	signers := make([]int, len(keys))
+	for i := range signers {
+		signers[i] = i
+	}
+	A, err := aggregatePublicKey(keys, signers)
+	if err != nil {
+		return Key{}, err
+	}
+	return *A, nil
+}
+
 func AggregateVerify(sig *Signature, publics []*Key, signers []int, message Hash) error {
 	A, err := aggregatePublicKey(publics, signers)
 	if err != nil {
@@ -0,0 +1,103 @@
+package crypto
+
+import (
+	"crypto/sha512"
+	"testing"
+
+	"filippo.io/edwards25519"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregatePublicKeys(t *testing.T) {
+	require := require.New(t)
+
+	_, err := AggregatePublicKeys(nil)
+	require.NotNil(err)
+
+	k1, k2, k3 := randomKey(), randomKey(), randomKey()
+	p1, p2, p3 := k1.Public(), k2.Public(), k3.Public()
+	A, err := AggregatePublicKeys([]*Key{&p1})
+	require.Nil(err)
+	require.Equal(p1, A)
+
+	s := edwards25519.NewScalar()
+	for _, k := range []Key{k1, k2, k3} {
+		y, err := edwards25519.NewScalar().SetCanonicalBytes(k[:])
+		require.Nil(err)
+		s = s.Add(s, y)
+	}
+	var expected Key
+	copy(expected[:], edwards25519.NewIdentityPoint().ScalarBaseMult(s).Bytes())
+	A, err = AggregatePublicKeys([]*Key{&p1, &p2, &p3})
+	require.Nil(err)
+	require.Equal(expected, A)
+	B, err := aggregatePublicKey([]*Key{&p1, &p2, &p3}, []int{0, 1, 2})
+	require.Nil(err)
+	require.Equal(A, *B)
+
+	invalid := Key{}
+	invalid[31] = 0xff
+	_, err = AggregatePublicKeys([]*Key{&p1, &invalid})
+	require.NotNil(err)
+}
+
+func TestAggregateVerifySmallOrder(t *testing.T) {
+	require := require.New(t)
+
+	identity := edwards25519.NewIdentityPoint()
+	for i, k := range SmallOrderPoints() {
+		p, err := edwards25519.NewIdentityPoint().SetBytes(k[:])
+		require.Nil(err)
+		require.Equal(k[:], p.Bytes())
+		require.Equal(1, edwards25519.NewIdentityPoint().MultByCofactor(p).Equal(identity))
+		if i > 0 {
+			require.Equal(0, p.Equal(identity))
+		}
+	}
+
+	k1, k2 := randomKey(), randomKey()
+	p1, p2 := k1.Public(), k2.Public()
+	publics := []*Key{&p1, &p2}
+	A, err := AggregatePublicKeys(publics)
+	require.Nil(err)
+	msg := Blake3Hash([]byte("aggregate small order"))
+	a := edwards25519.NewScalar()
+	for _, k := range []Key{k1, k2} {
+		y, err := edwards25519.NewScalar().SetCanonicalBytes(k[:])
+		require.Nil(err)
+		a = a.Add(a, y)
+	}
+	sign := func(R *edwards25519.Point, r *edwards25519.Scalar) Signature {
+		var digest [64]byte
+		h := sha512.New()
+		h.Write(R.Bytes())
+		h.Write(A[:])
+		h.Write(msg[:])
+		h.Sum(digest[:0])
+		x, err := edwards25519.NewScalar().SetUniformBytes(digest[:])
+		require.Nil(err)
+		var sig Signature
+		copy(sig[:32], R.Bytes())
+		copy(sig[32:], edwards25519.NewScalar().MultiplyAdd(x, a, r).Bytes())
+		return sig
+	}
+
+	nonce := randomKey()
+	r, err := edwards25519.NewScalar().SetCanonicalBytes(nonce[:])
+	require.Nil(err)
+	R := edwards25519.NewIdentityPoint().ScalarBaseMult(r)
+	sig := sign(R, r)
+	require.Nil(AggregateVerify(&sig, publics, []int{0, 1}, msg))
+
+	// a cofactored verifier accepts R with any small order component, but
+	// the kernel equation is cofactorless and must reject all of them
+	for _, k := range SmallOrderPoints()[1:] {
+		T, err := edwards25519.NewIdentityPoint().SetBytes(k[:])
+		require.Nil(err)
+		malicious := edwards25519.NewIdentityPoint().Add(R, T)
+		sig := sign(malicious, r)
+		require.NotNil(AggregateVerify(&sig, publics, []int{0, 1}, msg))
+		sig = sign(T, edwards25519.NewScalar())
+		require.NotNil(AggregateVerify(&sig, publics, []int{0, 1}, msg))
+	}
+}
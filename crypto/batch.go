@@ -21,6 +21,30 @@ func BatchVerify(msg Hash, keys []*Key, sigs []*Signature) bool {
 	return verifier.Verify()
 }
 
+// BatchVerifyMessages verifies signatures over independent messages in one
+// batch, and only when the batch fails, each signature is verified again to
+// report the indices of all the invalid ones.
+func BatchVerifyMessages(msgs []Hash, keys []*Key, sigs []*Signature) (bool, []int) {
+	if len(msgs) != len(keys) || len(keys) != len(sigs) || len(keys) == 0 {
+		return false, nil
+	}
+	verifier := NewBatchVerifier()
+	for i := range keys {
+		verifier.add(keys[i], msgs[i][:], sigs[i][:])
+	}
+	if verifier.Verify() {
+		return true, nil
+	}
+
+	var failed []int
+	for i := range keys {
+		if !keys[i].Verify(msgs[i], *sigs[i]) {
+			failed = append(failed, i)
+		}
+	}
+	return len(failed) == 0, failed
+}
+
 // Copyright (c) 2009 The Go Authors. All rights reserved.
 // Copyright (c) 2020 Henry de Valence. All rights reserved.
 
@@ -3,8 +3,48 @@ package crypto
 import (
 	"fmt"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
+func TestBatchVerifyMessages(t *testing.T) {
+	require := require.New(t)
+
+	var msgs []Hash
+	var pubs []*Key
+	var sigs []*Signature
+	for i := 0; i < 16; i++ {
+		seed := []byte(fmt.Sprintf("SEED%060d", i*128))
+		priv := NewKeyFromSeed(seed)
+		pub := priv.Public()
+		msg := Blake3Hash(seed)
+		sig := priv.Sign(msg)
+		msgs = append(msgs, msg)
+		pubs = append(pubs, &pub)
+		sigs = append(sigs, &sig)
+	}
+
+	valid, failed := BatchVerifyMessages(msgs, pubs, sigs)
+	require.True(valid)
+	require.Len(failed, 0)
+
+	msgs[3], msgs[11] = msgs[11], msgs[3]
+	valid, failed = BatchVerifyMessages(msgs, pubs, sigs)
+	require.False(valid)
+	require.Equal([]int{3, 11}, failed)
+	msgs[3], msgs[11] = msgs[11], msgs[3]
+
+	sigs[7] = sigs[0]
+	valid, failed = BatchVerifyMessages(msgs, pubs, sigs)
+	require.False(valid)
+	require.Equal([]int{7}, failed)
+
+	valid, _ = BatchVerifyMessages(msgs[1:], pubs, sigs)
+	require.False(valid)
+	valid, _ = BatchVerifyMessages(nil, nil, nil)
+	require.False(valid)
+}
+
 func BenchmarkVerifyBatch(b *testing.B) {
 	for _, n := range []int{1, 2, 4, 8, 64, 256} {
 		b.Run(fmt.Sprint(n), func(b *testing.B) {
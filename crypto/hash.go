@@ -20,6 +20,10 @@ func Blake3Hash(data []byte) Hash {
 	return Hash(blake3.Sum256(data))
 }
 
+// NonceHash derives the signing nonces from the seed, the nonces are never
+// verified by others, so tests can stub it, it should not change while signing.
+var NonceHash = Blake3Hash
+
 func HashFromString(src string) (Hash, error) {
 	var hash Hash
 	data, err := hex.DecodeString(src)
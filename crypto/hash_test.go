@@ -33,6 +33,12 @@ func TestHash(t *testing.T) {
 	require.Equal("9323516a9ed2b789339472e38673fd74e8e802efbb94b0b9454f0188ccb70357", h.String())
 	h, err = HashFromString("9323516a9ed2b789339472e38673fd74e8e802efbb94b0b9454f0188ccb7035")
 	require.NotNil(err)
+	_, err = HashFromString("9323516a9ed2b789339472e38673fd74e8e802efbb94b0b9454f0188ccb703")
+	require.Equal("invalid hash length 31", err.Error())
+	_, err = HashFromString("9323516a9ed2b789339472e38673fd74e8e802efbb94b0b9454f0188ccb7035800")
+	require.Equal("invalid hash length 33", err.Error())
+	_, err = HashFromString("9323516a9ed2b789339472e38673fd74e8e802efbb94b0b9454f0188ccb7035x")
+	require.NotNil(err)
 }
 
 func BenchmarkHash(b *testing.B) {
@@ -104,6 +104,15 @@ func HashScalar(k *edwards25519.Point, outputIndex uint64) *edwards25519.Scalar
 	return x
 }
 
+// GhostSharedSecret is the scalar Hs(a*R, index) = Hs(r*A, index) shared by both
+// the ghost public key and private key derivations, mask is R and view is a.
+func GhostSharedSecret(mask, view *Key, outputIndex uint64) Key {
+	x := HashScalar(KeyMultPubPriv(mask, view), outputIndex)
+	var key Key
+	copy(key[:], x.Bytes())
+	return key
+}
+
 func DeriveGhostPublicKey(r, A, B *Key, outputIndex uint64) *Key {
 	x := HashScalar(KeyMultPubPriv(A, r), outputIndex)
 	p1, err := edwards25519.NewIdentityPoint().SetBytes(B[:])
@@ -129,6 +138,57 @@ func DeriveGhostPrivateKey(R, a, b *Key, outputIndex uint64) *Key {
 	return &key
 }
 
+// DeriveGhostPrivateKeyRemoteView is DeriveGhostPrivateKey with the shared
+// view secret a*R, i.e. KeyMultPubPriv(mask, view), computed by the remote view
+// key holder, so the spend key holder never needs the view key. All keys are
+// untrusted input, the mask is only checked because it's already in the secret.
+func DeriveGhostPrivateKeyRemoteView(mask *Key, sharedViewSecret Key, spend *Key, index uint64) (Key, error) {
+	if !mask.CheckKey() {
+		return Key{}, fmt.Errorf("invalid ghost mask %s", mask)
+	}
+	aR, err := edwards25519.NewIdentityPoint().SetBytes(sharedViewSecret[:])
+	if err != nil {
+		return Key{}, fmt.Errorf("invalid shared view secret %s", sharedViewSecret)
+	}
+	y, err := edwards25519.NewScalar().SetCanonicalBytes(spend[:])
+	if err != nil {
+		return Key{}, fmt.Errorf("invalid ghost private spend key")
+	}
+	t := edwards25519.NewScalar().Add(HashScalar(aR, index), y)
+	var key Key
+	copy(key[:], t.Bytes())
+	return key, nil
+}
+
+// DeriveGhostPublicKeyChecked is DeriveGhostPublicKey returning an error for
+// the invalid keys instead of a panic.
+func DeriveGhostPublicKeyChecked(r, A, B *Key, outputIndex uint64) (*Key, error) {
+	if !checkScalar(r) {
+		return nil, fmt.Errorf("invalid ghost mask private key %s", r)
+	}
+	if !A.CheckKey() || !B.CheckKey() {
+		return nil, fmt.Errorf("invalid ghost public keys %s %s", A, B)
+	}
+	return DeriveGhostPublicKey(r, A, B, outputIndex), nil
+}
+
+// DeriveGhostPrivateKeyChecked is DeriveGhostPrivateKey returning an error for
+// the invalid keys instead of a panic.
+func DeriveGhostPrivateKeyChecked(R, a, b *Key, outputIndex uint64) (*Key, error) {
+	if !R.CheckKey() {
+		return nil, fmt.Errorf("invalid ghost mask %s", R)
+	}
+	if !checkScalar(a) || !checkScalar(b) {
+		return nil, fmt.Errorf("invalid ghost private keys")
+	}
+	return DeriveGhostPrivateKey(R, a, b, outputIndex), nil
+}
+
+func checkScalar(k *Key) bool {
+	_, err := edwards25519.NewScalar().SetCanonicalBytes(k[:])
+	return err == nil
+}
+
 func ViewGhostOutputKey(P, a, R *Key, outputIndex uint64) *Key {
 	x := HashScalar(KeyMultPubPriv(R, a), outputIndex)
 	p1, err := edwards25519.NewIdentityPoint().SetBytes(P[:])
@@ -142,6 +202,25 @@ func ViewGhostOutputKey(P, a, R *Key, outputIndex uint64) *Key {
 	return &key
 }
 
+// ViewGhostOutputKeys is ViewGhostOutputKey for all keys of the output, with
+// the shared aR = KeyMultPubPriv(R, a), so it's computed once for the mask.
+func ViewGhostOutputKeys(keys []*Key, aR *edwards25519.Point, outputIndex uint64) []*Key {
+	x := HashScalar(aR, outputIndex)
+	p2 := edwards25519.NewIdentityPoint().ScalarBaseMult(x)
+	views := make([]*Key, len(keys))
+	for i, P := range keys {
+		p1, err := edwards25519.NewIdentityPoint().SetBytes(P[:])
+		if err != nil {
+			panic(P.String())
+		}
+		p4 := edwards25519.NewIdentityPoint().Subtract(p1, p2)
+		var key Key
+		copy(key[:], p4.Bytes())
+		views[i] = &key
+	}
+	return views
+}
+
 func (k Key) String() string {
 	return hex.EncodeToString(k[:])
 }
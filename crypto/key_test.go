@@ -23,6 +23,18 @@ func TestKey(t *testing.T) {
 	require.Nil(err)
 	require.Equal("c91e0907d114fd83c1edc396490bb2dafa43c19815b0354e70dc80c317c3cb0a", key.String())
 	require.Equal("36bb0e309e7e9a82f1527df2c6b0e48181589097fe90c1282c558207ea27ce66", key.Public().String())
+
+	parsed, err := KeyFromString(key.String())
+	require.Nil(err)
+	require.Equal(key, parsed)
+	_, err = KeyFromString(key.String()[:62])
+	require.Equal("invalid key size 31", err.Error())
+	_, err = KeyFromString(key.String() + "00")
+	require.Equal("invalid key size 33", err.Error())
+	_, err = KeyFromString(key.String()[:63])
+	require.NotNil(err)
+	_, err = KeyFromString(key.String()[:62] + "zz")
+	require.NotNil(err)
 }
 
 func TestGhostKey(t *testing.T) {
@@ -57,6 +69,73 @@ func TestGhostKey(t *testing.T) {
 	require.True(A.Verify(ah, sig))
 }
 
+func TestGhostTestVectors(t *testing.T) {
+	require := require.New(t)
+
+	for _, v := range GhostTestVectors() {
+		A, B, R := v.PrivateView.Public(), v.PrivateSpend.Public(), v.PrivateMask.Public()
+		P := DeriveGhostPublicKey(&v.PrivateMask, &A, &B, v.Index)
+		require.Equal(v.GhostPublic, *P)
+		x := DeriveGhostPrivateKey(&R, &v.PrivateView, &v.PrivateSpend, v.Index)
+		require.Equal(v.GhostPrivate, *x)
+		require.Equal(v.GhostPublic, x.Public())
+		require.Equal(B, *ViewGhostOutputKey(P, &v.PrivateView, &R, v.Index))
+		secret := GhostSharedSecret(&R, &v.PrivateView, v.Index)
+		require.Equal(secret, GhostSharedSecret(&A, &v.PrivateMask, v.Index))
+		require.Equal(secret, *DeriveGhostPrivateKey(&R, &v.PrivateView, &Key{}, v.Index))
+		var aR Key
+		copy(aR[:], KeyMultPubPriv(&R, &v.PrivateView).Bytes())
+		remote, err := DeriveGhostPrivateKeyRemoteView(&R, aR, &v.PrivateSpend, v.Index)
+		require.Nil(err)
+		require.Equal(v.GhostPrivate, remote)
+		_, err = DeriveGhostPrivateKeyRemoteView(&R, Key{0xff, 0xff}, &v.PrivateSpend, v.Index)
+		require.NotNil(err)
+		var noncanonical Key
+		for i := range noncanonical {
+			noncanonical[i] = 0xff
+		}
+		_, err = DeriveGhostPrivateKeyRemoteView(&R, aR, &noncanonical, v.Index)
+		require.NotNil(err)
+		_, err = DeriveGhostPrivateKeyRemoteView(&Key{0xff, 0xff}, aR, &v.PrivateSpend, v.Index)
+		require.NotNil(err)
+		require.NotEqual(secret, GhostSharedSecret(&R, &v.PrivateView, v.Index+1))
+		views := ViewGhostOutputKeys([]*Key{P, P}, KeyMultPubPriv(&R, &v.PrivateView), v.Index)
+		require.Len(views, 2)
+		require.Equal(B, *views[0])
+		require.Equal(B, *views[1])
+	}
+}
+
+func FuzzDeriveGhostKey(f *testing.F) {
+	for _, v := range GhostTestVectors()[:2] {
+		R := v.PrivateMask.Public()
+		f.Add(R[:], v.PrivateView[:], v.PrivateSpend[:], v.Index)
+	}
+	f.Add(make([]byte, 32), make([]byte, 32), make([]byte, 32), uint64(0))
+	f.Add([]byte{0xff}, []byte{}, []byte{0xff, 0xff}, uint64(1<<63))
+
+	f.Fuzz(func(t *testing.T, m, v, s []byte, index uint64) {
+		var R, a, b Key
+		copy(R[:], m)
+		copy(a[:], v)
+		copy(b[:], s)
+
+		x, err := DeriveGhostPrivateKeyChecked(&R, &a, &b, index)
+		if err != nil {
+			require.Nil(t, x)
+		} else {
+			require.Equal(t, *DeriveGhostPrivateKey(&R, &a, &b, index), *x)
+		}
+
+		P, err := DeriveGhostPublicKeyChecked(&a, &R, &R, index)
+		if err != nil {
+			require.Nil(t, P)
+		} else {
+			require.Equal(t, *DeriveGhostPublicKey(&a, &R, &R, index), *P)
+		}
+	})
+}
+
 func randomKey() Key {
 	seed := make([]byte, 64)
 	ReadRand(seed)
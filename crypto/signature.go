@@ -20,6 +20,8 @@ func (s *Signature) S() []byte {
 	return s[32:]
 }
 
+// Sign is deterministic as ed25519, the nonce is derived from the private key
+// and the message, so the same key and message always yield the same signature.
 func (privateKey *Key) Sign(message Hash) Signature {
 	var digest1, messageDigest, hramDigest [64]byte
 
@@ -27,6 +27,7 @@ func testSignature(require *require.Assertions) {
 	msg2 := Blake3Hash(seed[32:])
 	sig1 := key1.Sign(msg1)
 	require.Equal("ca22e4ad608bad7638072e420ff5dd45eeb82c8e732e67580413066edf4cb8c0e2e6642f9e08b698a553a51e5719610b55d5afd1a9b9f3c69c6c60434dd55707", sig1.String())
+	require.Equal(sig1, key1.Sign(msg1))
 
 	seed2 := make([]byte, 64)
 	copy(seed2, sig1[:])
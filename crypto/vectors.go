@@ -0,0 +1,67 @@
+package crypto
+
+// GhostVector is a fixed ghost key derivation case, the receiver private view
+// key a and spend key b, the sender private mask r for the output index, the
+// ghost public key P = Hs(r*A, index)*G + B and its private key Hs(a*R, index) + b.
+type GhostVector struct {
+	PrivateView  Key
+	PrivateSpend Key
+	PrivateMask  Key
+	Index        uint64
+	GhostPublic  Key
+	GhostPrivate Key
+}
+
+func GhostTestVectors() []GhostVector {
+	vectors := []struct {
+		a, b, r string
+		index   uint64
+		P, x    string
+	}{
+		{"4fe2a684e0e6c5e370ca0d89f5e2cb0da1e2ecd4028fa2d395fbca4e33f25805", "9ec44d09c1cd8bc7e1941b12ebc5971b42c5d9a9051e45a72bf7959d66e4b10a", "00d3fe3087513f537cc231f801af8414e3a7c67e08ade77ac1f260ec99d60a00", 0, "703c804a529ce7063f58dc697b19e1ff491f6a8980495de37b3bc551ce6a0bb4", "7875b00a8746ed924a0d70f967a6385552cc5d152007c1e72858a4a435850f0d"},
+		{"4fb5a5b567380537ed8c3f81f7915022848ab3530b3c8a4e57ee2b3bcdc86305", "9e974c3a481fcb1a5e574d0aed741c30256da0280ecb2c22ede9f68900bbbc0a", "00a6fd610ea37ea6f88463f0035e0929c64f8dfd105acff582e5c1d833ad1500", 1, "251f1f43ffd90cb62c48d5ab0f5f20cb217a00043e131b6de879a97e9af3b41a", "be98db7fe5766a67bcb6196d7262230f4aa9c44d6e185468dc3440840a2ab006"},
+		{"4f88a4e6ee89448a694f7179f940d53667327ad213e971c918e18c27679f6e05", "9e6a4b6bcf700a6eda197f02ef23a144081567a71678149daedc57769a91c70a", "0079fc9295f4bdf9744795e8050d8e3da9f7537c1907b77044d822c5cd832000", 7, "24bbedbb5c7c1ba873f62ef981966e07416795c82e2a26f3f2581804abc06d39", "eb8f3249277ac37272f47a98ee41b585830c83b064bfee3e4b7fdadca4515202"},
+		{"4f5ba31776db83dde511a371fbef594b4ada40511c965944dad3ed1301767905", "9e3d4a9c56c249c156dcb0faf0d22559ebbc2d261f25fc1770cfb8623468d20a", "004cfbc31c46fd4cf109c7e007bc12528c9f1afb21b49eeb05cb83b1675a2b00", 255, "ad2d37eab44a1393ce370fe774040a00b509058be6baf4dd488c10d572506d51", "5089324f01fafdf9155b61ad3c8e5741b101ab7b4932add22bd6b667fab7b20d"},
+	}
+
+	gvs := make([]GhostVector, len(vectors))
+	for i, v := range vectors {
+		gvs[i] = GhostVector{
+			PrivateView:  mustKeyFromString(v.a),
+			PrivateSpend: mustKeyFromString(v.b),
+			PrivateMask:  mustKeyFromString(v.r),
+			Index:        v.index,
+			GhostPublic:  mustKeyFromString(v.P),
+			GhostPrivate: mustKeyFromString(v.x),
+		}
+	}
+	return gvs
+}
+
+// SmallOrderPoints are the canonical encodings of the 8 points in the small
+// subgroup of order 8, in the order of 1, 2, 4, 4, 8, 8, 8, 8.
+func SmallOrderPoints() []Key {
+	points := []string{
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000080",
+		"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a",
+		"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac03fa",
+		"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05",
+		"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc85",
+	}
+	keys := make([]Key, len(points))
+	for i, p := range points {
+		keys[i] = mustKeyFromString(p)
+	}
+	return keys
+}
+
+func mustKeyFromString(s string) Key {
+	key, err := KeyFromString(s)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
@@ -35,6 +35,10 @@ func (node *Node) Teardown() {
 	node.cacheStore.Clear()
 }
 
+func TestMockEnable(enable bool) {
+	clock.EnableMocking(enable)
+}
+
 func TestMockReset() {
 	clock.Reset()
 }
@@ -42,3 +46,7 @@ func TestMockReset() {
 func TestMockDiff(at time.Duration) {
 	clock.MockDiff(at)
 }
+
+func TestMockSource(ts clock.TimeSource) {
+	clock.SetSource(ts)
+}
@@ -346,7 +346,7 @@ func (chain *Chain) prepareAnnouncement(m *CosiAction) (bool, error) {
 		if s.Timestamp > cft+uint64(config.SnapshotRoundGap*4/5) {
 			return false, chain.clearAndQueueSnapshotOrPanic(s)
 		}
-		if s.Timestamp/OneDay != cft/OneDay {
+		if MintDay(s.Timestamp) != MintDay(cft) {
 			return false, chain.clearAndQueueSnapshotOrPanic(s)
 		}
 	}
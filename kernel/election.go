@@ -16,6 +16,13 @@ import (
 
 const MaxKernelNodesCount = 50
 
+// PledgeSchedule returns the pledge amounts by year, the pledge no longer
+// grows with time and every node pledges KernelNodePledgeAmount, so the
+// schedule has a single flat entry.
+func PledgeSchedule() []common.Integer {
+	return []common.Integer{common.KernelNodePledgeAmount}
+}
+
 func (node *Node) ElectionLoop() {
 	defer close(node.elc)
 
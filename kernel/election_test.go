@@ -8,6 +8,7 @@ import (
 	"github.com/MixinNetwork/mixin/common"
 	"github.com/MixinNetwork/mixin/config"
 	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/mixin/kernel/internal/clock"
 	"github.com/MixinNetwork/mixin/storage"
 	"github.com/dgraph-io/ristretto/v2"
 	"github.com/stretchr/testify/require"
@@ -93,6 +94,7 @@ ring-final-size = 16384
 listener = "mixin-node.example.com:7239"`)
 
 func setupTestNode(require *require.Assertions, dir string) *Node {
+	clock.EnableMocking(true)
 	err := os.WriteFile(dir+"/config.toml", configData, 0644)
 	require.Nil(err)
 
@@ -32,6 +32,11 @@ func TestGenesis(t *testing.T) {
 		require.Equal(common.NodeStateAccepted, n.State)
 		require.Equal(genesisNodes[i], n.IdForNetwork.String())
 	}
+	accepted, err := node.ListAcceptedNodes(uint64(now.UnixNano()) + 1)
+	require.Nil(err)
+	require.Equal(nodes, accepted)
+	_, err = node.ListAcceptedNodes(uint64(now.UnixNano()))
+	require.NotNil(err)
 
 	snapshots, err := node.persistStore.ReadSnapshotsSinceTopology(0, 100)
 	require.Nil(err)
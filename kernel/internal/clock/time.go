@@ -2,53 +2,114 @@ package clock
 
 import (
 	"fmt"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/MixinNetwork/mixin/config"
 	"github.com/MixinNetwork/mixin/logger"
 )
 
 // FIXME GLOBAL VARIABLES
 
 var (
-	inTest   = strings.Contains(config.BuildVersion, "BUILD_VERSION")
+	mocking  atomic.Bool
 	mutex    = new(sync.RWMutex)
 	mockDiff = time.Duration(0)
+	source   TimeSource
+	observer func(at, total time.Duration)
 )
 
-func Reset() {
-	if !inTest {
-		panic(fmt.Errorf("clock reset not allowed in build version %s", config.BuildVersion))
+// EnableMocking is called by the test harness before any mock, all mocks
+// panic and Now is always the system time while it's disabled.
+func EnableMocking(enable bool) {
+	mocking.Store(enable)
+}
+
+// TimeSource replaces the system time in tests, the mock diff still applies
+// on top of it.
+type TimeSource interface {
+	Now() time.Time
+}
+
+func SetSource(ts TimeSource) {
+	if !mocking.Load() {
+		panic(fmt.Errorf("clock source not allowed without mocking"))
 	}
 
 	mutex.Lock()
 	defer mutex.Unlock()
+	source = ts
+}
+
+// SetMockObserver makes MockDiff and Reset report each jump to fn instead
+// of logging it, Reset reports the reverted diff as negative.
+func SetMockObserver(fn func(at, total time.Duration)) {
+	if !mocking.Load() {
+		panic(fmt.Errorf("clock observer not allowed without mocking"))
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	observer = fn
+}
+
+func Reset() {
+	if !mocking.Load() {
+		panic(fmt.Errorf("clock reset not allowed without mocking"))
+	}
+
+	mutex.Lock()
+	at, fn := -mockDiff, observer
 	mockDiff = 0
+	mutex.Unlock()
+
+	if fn != nil {
+		fn(at, 0)
+	}
 }
 
 func MockDiff(at time.Duration) {
-	if !inTest {
-		panic(fmt.Errorf("clock mock not allowed in build version %s", config.BuildVersion))
+	if !mocking.Load() {
+		panic(fmt.Errorf("clock mock not allowed without mocking"))
 	}
 
 	mutex.Lock()
-	defer mutex.Unlock()
 	mockDiff += at
-	logger.Printf("clock.MockDiff(%s) => %s\n", at, time.Now().Add(mockDiff))
+	total, fn, t := mockDiff, observer, now().Add(mockDiff)
+	mutex.Unlock()
+
+	if fn != nil {
+		fn(at, total)
+	} else {
+		logger.Printf("clock.MockDiff(%s) => %s\n", at, t)
+	}
 }
 
 func Now() time.Time {
-	if !inTest {
+	if !mocking.Load() {
 		return time.Now()
 	}
 
 	mutex.RLock()
 	defer mutex.RUnlock()
-	return time.Now().Add(mockDiff)
+	return now().Add(mockDiff)
+}
+
+func now() time.Time {
+	if source != nil {
+		return source.Now()
+	}
+	return time.Now()
 }
 
 func NowUnixNano() uint64 {
 	return uint64(Now().UnixNano())
 }
+
+func NowUnixMilli() uint64 {
+	return uint64(Now().UnixMilli())
+}
+
+func NowUnixSeconds() uint64 {
+	return uint64(Now().Unix())
+}
@@ -0,0 +1,80 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fixedSource struct {
+	t time.Time
+}
+
+func (fs *fixedSource) Now() time.Time {
+	return fs.t
+}
+
+func TestClockSource(t *testing.T) {
+	require := require.New(t)
+	EnableMocking(true)
+	defer EnableMocking(false)
+	defer Reset()
+	defer SetSource(nil)
+
+	fs := &fixedSource{t: time.Unix(1700000000, 0)}
+	SetSource(fs)
+	require.Equal(fs.t, Now())
+	require.Equal(uint64(fs.t.UnixNano()), NowUnixNano())
+	require.Equal(uint64(1700000000000), NowUnixMilli())
+	require.Equal(uint64(1700000000), NowUnixSeconds())
+
+	fs.t = fs.t.Add(time.Hour * 24)
+	require.Equal(fs.t, Now())
+	MockDiff(time.Minute)
+	require.Equal(fs.t.Add(time.Minute), Now())
+
+	SetSource(nil)
+	Reset()
+	require.WithinDuration(time.Now(), Now(), time.Second)
+}
+
+func TestMockObserver(t *testing.T) {
+	require := require.New(t)
+	EnableMocking(true)
+	defer EnableMocking(false)
+	defer SetMockObserver(nil)
+
+	var jumps [][2]time.Duration
+	SetMockObserver(func(at, total time.Duration) {
+		jumps = append(jumps, [2]time.Duration{at, total})
+		require.WithinDuration(time.Now().Add(total), Now(), time.Second)
+	})
+	MockDiff(time.Hour * 24)
+	MockDiff(-time.Hour)
+	Reset()
+	require.Equal([][2]time.Duration{
+		{time.Hour * 24, time.Hour * 24},
+		{-time.Hour, time.Hour * 23},
+		{-time.Hour * 23, 0},
+	}, jumps)
+}
+
+func TestEnableMocking(t *testing.T) {
+	require := require.New(t)
+
+	require.Panics(func() { MockDiff(time.Hour) })
+	require.Panics(func() { Reset() })
+	require.Panics(func() { SetSource(nil) })
+	require.Panics(func() { SetMockObserver(nil) })
+	require.WithinDuration(time.Now(), Now(), time.Second)
+
+	EnableMocking(true)
+	MockDiff(time.Hour)
+	require.WithinDuration(time.Now().Add(time.Hour), Now(), time.Second)
+	EnableMocking(false)
+	require.WithinDuration(time.Now(), Now(), time.Second)
+	EnableMocking(true)
+	Reset()
+	EnableMocking(false)
+}
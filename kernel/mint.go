@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/MixinNetwork/mixin/common"
@@ -26,6 +27,17 @@ const (
 	OneDay                    = 24 * uint64(time.Hour)
 )
 
+// MintDay is the absolute day of the timestamp, the key of node works.
+func MintDay(timestamp uint64) uint32 {
+	return uint32(timestamp / OneDay)
+}
+
+// MintBatch is the mint batch of the timestamp, the days since the epoch day,
+// both are truncated to days separately, so it's not (timestamp-epoch)/OneDay.
+func MintBatch(epoch, timestamp uint64) uint64 {
+	return timestamp/OneDay - epoch/OneDay
+}
+
 func (chain *Chain) AggregateMintWork() {
 	logger.Printf("AggregateMintWork(%s)\n", chain.ChainId)
 	defer close(chain.wlc)
@@ -63,7 +75,7 @@ func (chain *Chain) AggregateMintWork() {
 			logger.Printf("AggregateMintWork(%s) ERROR ReadSnapshotsForNodeRound %s\n", chain.ChainId, err.Error())
 			continue
 		}
-		rd := snapshots[0].Timestamp / OneDay
+		rd := MintDay(snapshots[0].Timestamp)
 		if rd > md {
 			panic(fmt.Errorf("AggregateMintWork(%s) %d %d %d", chain.ChainId, round, rd, md))
 		}
@@ -81,7 +93,7 @@ func (chain *Chain) AggregateMintWork() {
 	logger.Printf("AggregateMintWork(%s) end with %d\n", chain.ChainId, round)
 }
 
-func (chain *Chain) checkRoundMature(round uint64) (uint64, bool) {
+func (chain *Chain) checkRoundMature(round uint64) (uint32, bool) {
 	cache := chain.State.CacheRound
 	if cache.Number < round {
 		panic(fmt.Errorf("AggregateMintWork(%s) waiting %d %d", chain.ChainId, cache.Number, round))
@@ -93,16 +105,17 @@ func (chain *Chain) checkRoundMature(round uint64) (uint64, bool) {
 		if len(cache.Snapshots) < 1 {
 			return 0, false
 		}
-		return cache.Snapshots[0].Timestamp / OneDay, true
+		return MintDay(cache.Snapshots[0].Timestamp), true
 	}
 	snapshots, err := chain.persistStore.ReadSnapshotWorksForNodeRound(chain.ChainId, round+1)
 	if err != nil {
 		panic(err)
 	}
-	return snapshots[0].Timestamp / OneDay, true
+	return MintDay(snapshots[0].Timestamp), true
 }
 
 func (chain *Chain) writeRoundWork(round uint64, works []*common.SnapshotWork, credit bool) error {
+	// the mainnet fork gap was applied with this formula, must not use MintBatch
 	credit = credit || (chain.node.networkId.String() == config.KernelNetworkId &&
 		(works[0].Timestamp-chain.node.Epoch)/OneDay < mainnetMintDayGapSkipForkBatch)
 	for chain.running {
@@ -198,7 +211,10 @@ func (node *Node) buildUniversalMintTransaction(custodianRequest *common.Custodi
 		seed := append(si[:], si[:]...)
 		script := common.NewThresholdScript(1)
 		tx.AddScriptOutput([]*common.Address{&m.Payee}, script, m.Work, seed)
-		total = total.Add(m.Work)
+		total, err = total.AddChecked(m.Work)
+		if err != nil {
+			panic(fmt.Errorf("buildUniversalMintTransaction %s %v", amount, err))
+		}
 	}
 	if total.Cmp(amount) > 0 {
 		panic(fmt.Errorf("buildUniversalMintTransaction %s %s", amount, total))
@@ -252,6 +268,32 @@ func (node *Node) lastMintDistribution() *common.MintData {
 	return &dist.MintData
 }
 
+// ValidateMintAmount checks the mint outputs of the batch day don't exceed
+// the issuance of the batch, as checkUniversalMintPossibility produces. The
+// legacy mint is the first one after the legacy kernel ending, it covers all
+// batches since then, otherwise the mint covers the single batch day.
+func ValidateMintAmount(day uint32, outputs []*common.Output, legacy bool) error {
+	if day <= KernelNetworkLegacyEnding {
+		return fmt.Errorf("invalid mint batch %d", day)
+	}
+	total := common.Zero
+	for i, o := range outputs {
+		sum, err := total.AddChecked(o.Amount)
+		if err != nil {
+			return fmt.Errorf("invalid mint output %d %v", i, err)
+		}
+		total = sum
+	}
+	amount := mintBatchSize(uint64(day))
+	if legacy {
+		amount = mintMultiBatchesSize(KernelNetworkLegacyEnding, uint64(day))
+	}
+	if total.Cmp(amount) > 0 {
+		return fmt.Errorf("mint amount %s exceeds batch size %s at %d", total, amount, day)
+	}
+	return nil
+}
+
 func poolSizeUniversal(batch int) common.Integer {
 	mint, pool := common.Zero, MintPool
 	for i := 0; i < batch/MintYearDays; i++ {
@@ -358,6 +400,16 @@ func (node *Node) checkUniversalMintPossibility(timestamp uint64, validateOnly b
 	return batch, amount
 }
 
+// OrderSignersForMint returns the genesis nodes in their genesis order with
+// self appended last unless it's a genesis node, the genesis slice is copied.
+func OrderSignersForMint(genesis []crypto.Hash, self crypto.Hash) []crypto.Hash {
+	signers := slices.Clone(genesis)
+	if !slices.Contains(signers, self) {
+		signers = append(signers, self)
+	}
+	return signers
+}
+
 type CNodeWork struct {
 	CNode
 	Work common.Integer
@@ -370,7 +422,7 @@ func (node *Node) ListMintWorks(batch uint64) (map[crypto.Hash][2]uint64, error)
 	for i, n := range list {
 		cids[i] = n.IdForNetwork
 	}
-	return node.persistStore.ListNodeWorks(cids, uint32(now/OneDay))
+	return node.persistStore.ListNodeWorks(cids, MintDay(now))
 }
 
 func (node *Node) ListRoundSpaces(cids []crypto.Hash, day uint64) (map[crypto.Hash][]*common.RoundSpace, error) {
@@ -399,11 +451,11 @@ func (node *Node) distributeKernelMintByWorks(accepted []*CNode, base common.Int
 		mints[i] = &CNodeWork{CNode: *n}
 	}
 	epoch := node.Epoch / OneDay
-	day := timestamp / OneDay
+	day := uint64(MintDay(timestamp))
 	if day < epoch {
 		panic(fmt.Errorf("invalid mint day %d %d", epoch, day))
 	}
-	if day-epoch == 0 {
+	if MintBatch(node.Epoch, timestamp) == 0 {
 		work := base.Div(len(mints))
 		for _, m := range mints {
 			m.Work = work
@@ -426,8 +478,6 @@ func (node *Node) distributeKernelMintByWorks(accepted []*CNode, base common.Int
 		return nil, err
 	}
 
-	var valid int
-	var minW, maxW, totalW common.Integer
 	for _, m := range mints {
 		ns := spaces[m.IdForNetwork]
 		if len(ns) > 0 {
@@ -436,57 +486,92 @@ func (node *Node) distributeKernelMintByWorks(accepted []*CNode, base common.Int
 			// otherwise this will not work in low transaction conditions
 			logger.Verbosef("node spaces %s %d %d\n", m.IdForNetwork, ns[0].Batch, len(ns))
 		}
+	}
+
+	shares, err := ComputeMintShares(works, cids, base, thr)
+	if err != nil {
+		return nil, fmt.Errorf("distributeKernelMintByWorks not valid %d %d %v", day, len(mints), err)
+	}
+	for i, m := range mints {
+		m.Work = shares[i]
+	}
+	return mints, nil
+}
 
-		w := works[m.IdForNetwork]
-		m.Work = common.NewInteger(w[0]).Mul(120).Div(100)
+// ComputeMintShares distributes the base to the accepted nodes by their works,
+// the shares are in the order of accepted, at least thr nodes should work.
+func ComputeMintShares(works map[crypto.Hash][2]uint64, accepted []crypto.Hash, base common.Integer, thr int) ([]common.Integer, error) {
+	var valid int
+	var minW, maxW, totalW common.Integer
+	shares := make([]common.Integer, len(accepted))
+	for i, id := range accepted {
+		w := works[id]
+		shares[i] = common.NewInteger(w[0]).Mul(120).Div(100)
 		sign := common.NewInteger(w[1])
 		if sign.Sign() > 0 {
-			m.Work = m.Work.Add(sign)
+			shares[i] = shares[i].Add(sign)
 		}
-		if m.Work.Sign() == 0 {
+		if shares[i].Sign() == 0 {
 			continue
 		}
 		valid += 1
 		if minW.Sign() == 0 {
-			minW = m.Work
-		} else if m.Work.Cmp(minW) < 0 {
-			minW = m.Work
+			minW = shares[i]
+		} else if shares[i].Cmp(minW) < 0 {
+			minW = shares[i]
 		}
-		if m.Work.Cmp(maxW) > 0 {
-			maxW = m.Work
+		if shares[i].Cmp(maxW) > 0 {
+			maxW = shares[i]
 		}
-		totalW = totalW.Add(m.Work)
+		totalW = totalW.Add(shares[i])
 	}
 	if valid < thr {
-		return nil, fmt.Errorf("distributeKernelMintByWorks not valid %d %d %d %d",
-			day, len(mints), thr, valid)
+		return nil, fmt.Errorf("invalid mint works %d %d", thr, valid)
 	}
 
 	totalW = totalW.Sub(minW).Sub(maxW)
 	avg := totalW.Div(valid - 2)
 	if avg.Sign() == 0 {
-		return nil, fmt.Errorf("distributeKernelMintByWorks not valid %d %d %d %d",
-			day, len(mints), thr, valid)
+		return nil, fmt.Errorf("invalid mint works average %d %d", thr, valid)
 	}
 
 	totalW = common.NewInteger(0)
 	upper, lower := avg.Mul(7), avg.Div(7)
-	for _, m := range mints {
-		if m.Work.Cmp(upper) >= 0 {
-			m.Work = avg.Mul(2)
-		} else if m.Work.Cmp(avg) >= 0 {
-			m.Work = m.Work.Div(6).Add(avg.Mul(5).Div(6))
-		} else if m.Work.Cmp(lower) <= 0 {
-			m.Work = avg.Div(7)
+	for i := range shares {
+		if shares[i].Cmp(upper) >= 0 {
+			shares[i] = avg.Mul(2)
+		} else if shares[i].Cmp(avg) >= 0 {
+			shares[i] = shares[i].Div(6).Add(avg.Mul(5).Div(6))
+		} else if shares[i].Cmp(lower) <= 0 {
+			shares[i] = avg.Div(7)
 		}
-		totalW = totalW.Add(m.Work)
+		totalW = totalW.Add(shares[i])
 	}
 
-	for _, m := range mints {
-		rat := m.Work.Ration(totalW)
-		m.Work = rat.Product(base)
+	for i := range shares {
+		rat := shares[i].Ration(totalW)
+		shares[i] = rat.Product(base)
 	}
-	return mints, nil
+	return shares, nil
+}
+
+// VerifyNodeMintShare errors if the claimed mint of node differs from its share
+// by ComputeMintShares, more than the one unit truncated by the division.
+func VerifyNodeMintShare(works map[crypto.Hash][2]uint64, accepted []crypto.Hash, amount common.Integer, node crypto.Hash, claimed common.Integer) error {
+	i := slices.Index(accepted, node)
+	if i < 0 {
+		return fmt.Errorf("node %s not accepted for mint", node)
+	}
+	// the minimum and maximum works are excluded from the average
+	shares, err := ComputeMintShares(works, accepted, amount, 3)
+	if err != nil {
+		return err
+	}
+	delta, _ := shares[i].Delta(claimed)
+	if delta.Cmp(common.NewIntegerFromString("0.00000001")) > 0 {
+		return fmt.Errorf("invalid mint share of node %s %s %s", node, shares[i], claimed)
+	}
+	return nil
 }
 
 func (node *Node) validateWorksAndSpacesAggregator(cids []crypto.Hash, thr int, day uint64) error {
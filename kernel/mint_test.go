@@ -10,6 +10,7 @@ import (
 	"github.com/MixinNetwork/mixin/kernel/internal"
 	"github.com/MixinNetwork/mixin/kernel/internal/clock"
 	"github.com/MixinNetwork/mixin/logger"
+	"github.com/MixinNetwork/mixin/storage"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,6 +18,20 @@ func TestPledgeAmount(t *testing.T) {
 	require := require.New(t)
 
 	require.Equal(common.NewIntegerFromString("13439"), common.KernelNodePledgeAmount)
+	require.Equal([]common.Integer{common.NewInteger(13439)}, PledgeSchedule())
+}
+
+func TestMintDayBatch(t *testing.T) {
+	require := require.New(t)
+
+	epoch := uint64(1551312000000000000)
+	require.Equal(uint32(17955), MintDay(epoch))
+	require.Equal(uint32(17955), MintDay(epoch+OneDay-1))
+	require.Equal(uint32(17956), MintDay(epoch+OneDay))
+	require.Equal(uint64(0), MintBatch(epoch, epoch))
+	require.Equal(uint64(1), MintBatch(epoch, epoch+OneDay))
+	require.Equal(uint64(0), MintBatch(epoch+OneDay/2, epoch+OneDay-1))
+	require.Equal(uint64(1), MintBatch(epoch+OneDay/2, epoch+OneDay))
 }
 
 func TestMintBatchSize(t *testing.T) {
@@ -43,6 +58,48 @@ func TestMintBatchSize(t *testing.T) {
 	require.Equal(common.NewIntegerFromString("29443.61095650"), mintMultiBatchesSize(1707, 2058))
 }
 
+func TestVerifyNodeMintShare(t *testing.T) {
+	require := require.New(t)
+
+	accepted := make([]crypto.Hash, 5)
+	works := make(map[crypto.Hash][2]uint64)
+	for i, w := range [][2]uint64{{100, 0}, {120, 10}, {0, 0}, {110, 5}, {1300, 0}} {
+		accepted[i] = crypto.Blake3Hash([]byte(fmt.Sprintf("node%d", i)))
+		works[accepted[i]] = w
+	}
+	amount := common.NewInteger(1000)
+	shares, err := ComputeMintShares(works, accepted, amount, 4)
+	require.Nil(err)
+	require.Len(shares, 5)
+	total := common.NewInteger(0)
+	for _, s := range shares {
+		total = total.Add(s)
+	}
+	require.True(total.Cmp(amount) <= 0)
+	require.True(shares[2].Sign() > 0)
+	require.True(shares[4].Cmp(shares[1]) > 0)
+	_, err = ComputeMintShares(works, accepted, amount, 5)
+	require.NotNil(err)
+
+	for i, id := range accepted {
+		require.Nil(VerifyNodeMintShare(works, accepted, amount, id, shares[i]))
+	}
+	unit := common.NewIntegerFromString("0.00000001")
+	require.Nil(VerifyNodeMintShare(works, accepted, amount, accepted[0], shares[0].Add(unit)))
+	err = VerifyNodeMintShare(works, accepted, amount, accepted[0], shares[0].Add(unit).Add(unit))
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid mint share")
+	err = VerifyNodeMintShare(works, accepted, amount, accepted[0], shares[1])
+	require.NotNil(err)
+	err = VerifyNodeMintShare(works, accepted, amount, crypto.Blake3Hash([]byte("node")), shares[0])
+	require.NotNil(err)
+	require.Contains(err.Error(), "not accepted")
+	works[accepted[1]], works[accepted[3]] = [2]uint64{}, [2]uint64{}
+	err = VerifyNodeMintShare(works, accepted, amount, accepted[0], shares[0])
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid mint works 3 2")
+}
+
 func TestPoolSize(t *testing.T) {
 	require := require.New(t)
 
@@ -55,6 +112,29 @@ func TestPoolSize(t *testing.T) {
 	require.Equal(common.NewIntegerFromString("305850.45205696"), poolSizeUniversal(1707))
 
 	require.True(common.NewInteger(500000).Sub(poolSizeUniversal(1707)).Cmp(mintMultiBatchesSize(0, 1707)) > 0)
+
+	outputs := []*common.Output{
+		{Amount: common.NewIntegerFromString("44.93835616")},
+		{Amount: common.NewIntegerFromString("44.93835616")},
+	}
+	require.Nil(ValidateMintAmount(1707, outputs, false))
+	require.Nil(ValidateMintAmount(1707, outputs, true))
+	require.Nil(ValidateMintAmount(1708, outputs, false))
+	require.NotNil(ValidateMintAmount(1706, outputs, false))
+	require.NotNil(ValidateMintAmount(1706, outputs, true))
+	require.Nil(ValidateMintAmount(1826, outputs[1:], false))
+	err := ValidateMintAmount(1826, outputs, false)
+	require.NotNil(err)
+	require.Contains(err.Error(), "exceeds batch size 80.88904109")
+	outputs[1].Amount = common.NewIntegerFromString("44.93835617")
+	err = ValidateMintAmount(1707, outputs, false)
+	require.NotNil(err)
+	require.Contains(err.Error(), "exceeds batch size 89.87671232")
+	require.Nil(ValidateMintAmount(1708, outputs, true))
+	outputs[1].Amount = common.NewIntegerFromString("134.81506849")
+	require.NotNil(ValidateMintAmount(1708, outputs, true))
+	outputs[1].Amount = common.Zero
+	require.NotNil(ValidateMintAmount(1707, outputs, false))
 }
 
 func TestUniversalMintTransaction(t *testing.T) {
@@ -109,6 +189,9 @@ func TestUniversalMintTransaction(t *testing.T) {
 	node.TopoWrite(snap, []crypto.Hash{snap.NodeId})
 
 	signers := node.genesisNodes
+	_, _, err = node.persistStore.NodeWorkActiveRange(node.IdForNetwork)
+	require.NotNil(err)
+	var days []uint32
 	for _, tr := range []struct {
 		diff  time.Duration
 		round uint64
@@ -134,6 +217,7 @@ func TestUniversalMintTransaction(t *testing.T) {
 			works, err := node.persistStore.ListNodeWorks(signers, day)
 			require.Nil(err)
 			require.Len(works, len(signers))
+			days = append(days, day)
 		}
 
 		batch := (timestamp - node.Epoch) / (24 * uint64(time.Hour))
@@ -151,6 +235,16 @@ func TestUniversalMintTransaction(t *testing.T) {
 		}
 	}
 
+	first, last, err := node.persistStore.NodeWorkActiveRange(node.IdForNetwork)
+	require.Nil(err)
+	require.Equal(days[0], first)
+	require.Equal(days[len(days)-1], last)
+	require.Less(first, last)
+	first, last, err = node.persistStore.NodeWorkActiveRange(signers[1])
+	require.Nil(err)
+	require.Equal(days[0], first)
+	require.Equal(days[len(days)-1], last)
+
 	timestamp := clock.NowUnixNano()
 	cur := &common.CustodianUpdateRequest{Custodian: &custodian}
 	versioned = node.buildUniversalMintTransaction(cur, timestamp, false)
@@ -194,11 +288,18 @@ func TestMintWorks(t *testing.T) {
 	require.Nil(err)
 	require.Equal(uint64(0), offset)
 
-	signers := append(node.genesisNodes, node.IdForNetwork)
+	signers := OrderSignersForMint(node.genesisNodes, node.IdForNetwork)
+	require.Equal(node.genesisNodes, signers[:len(node.genesisNodes)])
+	require.Equal(signers, OrderSignersForMint(signers, node.IdForNetwork))
 	timestamp := clock.NowUnixNano()
 	leaders := len(signers)*2/3 + 1
 	for i := 0; i < 2; i++ {
 		snapshots := testBuildMintSnapshots(signers[1:], 0, timestamp)
+		accumulated := storage.AccumulateWorks(snapshots, node.IdForNetwork)
+		require.Len(accumulated, len(signers)-1)
+		require.Equal([2]uint64{100, 0}, accumulated[node.IdForNetwork])
+		require.Equal([2]uint64{0, 100}, accumulated[signers[1]])
+		require.Equal([2]uint64{100, 0}, storage.AccumulateWorks(snapshots, signers[1])[signers[1]])
 		err = node.persistStore.WriteRoundWork(node.IdForNetwork, 0, snapshots, true)
 		require.Nil(err)
 		for j := 1; j < leaders; j++ {
@@ -257,6 +358,19 @@ func TestMintWorks(t *testing.T) {
 	offset, err = node.persistStore.ReadWorkOffset(node.IdForNetwork)
 	require.Nil(err)
 	require.Equal(uint64(1), offset)
+	last, err := node.persistStore.ReadLastSnapshotTimestamp(node.IdForNetwork)
+	require.Nil(err)
+	require.Equal(snapshots[97].Timestamp, last)
+	err = node.persistStore.WriteLastSnapshotTimestamp(node.IdForNetwork, last-1)
+	require.Nil(err)
+	last, err = node.persistStore.ReadLastSnapshotTimestamp(node.IdForNetwork)
+	require.Nil(err)
+	require.Equal(snapshots[97].Timestamp, last)
+	err = node.persistStore.WriteLastSnapshotTimestamp(node.IdForNetwork, last+1)
+	require.Nil(err)
+	last, err = node.persistStore.ReadLastSnapshotTimestamp(node.IdForNetwork)
+	require.Nil(err)
+	require.Equal(snapshots[97].Timestamp+1, last)
 
 	err = node.persistStore.WriteRoundWork(node.IdForNetwork, 1, snapshots, true)
 	require.Nil(err)
@@ -335,6 +449,8 @@ func TestMintWorks(t *testing.T) {
 	require.Equal(common.NewInteger(10000).Sub(total).String(), "0.00000016")
 }
 
+// rounds have no protocol size, they are closed by the round gap, so 100 is
+// only an arbitrary size for the tests
 func testBuildMintSnapshots(signers []crypto.Hash, round, timestamp uint64) []*common.SnapshotWork {
 	snapshots := make([]*common.SnapshotWork, 100)
 	for i := range snapshots {
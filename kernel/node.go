@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"maps"
 	"math"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -166,6 +167,16 @@ func (node *Node) NodesListWithoutState(threshold uint64, acceptedOnly bool) []*
 	return nil
 }
 
+// ListAcceptedNodes returns the accepted nodes in effect at the timestamp, the
+// same set used to distribute the kernel mint at the timestamp.
+func (node *Node) ListAcceptedNodes(at uint64) ([]*CNode, error) {
+	nodes := node.NodesListWithoutState(at, true)
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no accepted nodes at %d", at)
+	}
+	return slices.Clone(nodes), nil
+}
+
 func (node *Node) nodeSequenceWithoutState(threshold uint64, acceptedOnly bool) []*CNode {
 	filter := make(map[crypto.Hash]*CNode)
 	for _, n := range node.allNodesSortedWithState {
@@ -374,7 +385,7 @@ func (node *Node) listenConsumers() {
 
 func (node *Node) BuildAuthenticationMessage(relayerId crypto.Hash) []byte {
 	data := make([]byte, 8)
-	binary.BigEndian.PutUint64(data, uint64(clock.Now().Unix()))
+	binary.BigEndian.PutUint64(data, clock.NowUnixSeconds())
 	data = append(data, relayerId[:]...)
 	data = append(data, node.Signer.PublicSpendKey[:]...)
 	if node.isRelayer {
@@ -209,7 +209,7 @@ func (c *CacheRound) validateSnapshot(s *common.Snapshot, add bool) error {
 		if cs.Hash == s.Hash || cs.Timestamp == s.Timestamp || cs.SoleTransaction() == s.SoleTransaction() {
 			return fmt.Errorf("ValidateSnapshot error duplication %s %d %s", s.Hash, s.Timestamp, s.SoleTransaction())
 		}
-		if cs.Timestamp/OneDay != s.Timestamp/OneDay {
+		if MintDay(cs.Timestamp) != MintDay(s.Timestamp) {
 			return fmt.Errorf("ValidateSnapshot error round day leap %s %d %s", s.Hash, s.Timestamp, s.SoleTransaction())
 		}
 	}
@@ -37,6 +37,7 @@ func TestConsensus(t *testing.T) {
 
 func testConsensus(t *testing.T, extrenalRelayers bool) {
 	require := require.New(t)
+	kernel.TestMockEnable(true)
 	kernel.TestMockReset()
 	startAt := time.Now()
 
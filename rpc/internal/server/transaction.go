@@ -96,6 +96,7 @@ func getUTXO(store storage.Store, params []any) (map[string]any, error) {
 		"hash":   hash,
 		"index":  index,
 		"amount": utxo.Amount,
+		"asset":  utxo.Asset,
 	}
 	if len(utxo.Keys) > 0 {
 		output["keys"] = utxo.Keys
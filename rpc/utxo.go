@@ -25,6 +25,9 @@ func (ur *utxoKeysRPCReader) ReadUTXOKeys(hash crypto.Hash, index uint) (*common
 	}
 	utxo.Keys = out.Keys
 	utxo.Mask = out.Mask
+	utxo.Script = out.Script
+	utxo.Asset = out.Asset
+	utxo.Amount = out.Amount
 	return utxo, nil
 }
 
@@ -42,6 +45,7 @@ func GetUTXO(rpc, hash string, index uint64) (*common.UTXOWithLock, error) {
 		Hash     crypto.Hash    `json:"hash"`
 		Index    uint           `json:"index"`
 		Amount   common.Integer `json:"amount"`
+		Asset    crypto.Hash    `json:"asset"`
 		Keys     []*crypto.Key  `json:"keys"`
 		Script   common.Script  `json:"script"`
 		Mask     *crypto.Key    `json:"mask"`
@@ -57,6 +61,7 @@ func GetUTXO(rpc, hash string, index uint64) (*common.UTXOWithLock, error) {
 	utxo.Hash = out.Hash
 	utxo.Index = out.Index
 	utxo.Amount = out.Amount
+	utxo.Asset = out.Asset
 	utxo.Keys = out.Keys
 	utxo.Script = out.Script
 	utxo.Mask = *out.Mask
@@ -31,6 +31,29 @@ func (s *BadgerStore) ReadDepositLock(deposit *common.DepositData) (crypto.Hash,
 	return hash, nil
 }
 
+// DepositExists reports whether the deposit with the same chain, transaction
+// and index is locked by a finalized transaction, a pending lock could still
+// be replaced by a fork, ReadDepositLock returns it.
+func (s *BadgerStore) DepositExists(deposit *common.DepositData) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	ival, err := readDepositInput(txn, deposit)
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	_, err = txn.Get(graphFinalizationKey(crypto.Hash(ival)))
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 func (s *BadgerStore) LockDepositInput(deposit *common.DepositData, tx crypto.Hash, fork bool) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
 	"github.com/dgraph-io/badger/v4"
 )
 
@@ -55,6 +56,32 @@ func (s *BadgerStore) LoadGenesis(rounds []*common.Round, snapshots []*common.Sn
 	return txn.Commit()
 }
 
+// ReadNetworkParams reads the epoch and network id from the first genesis
+// snapshot, its timestamp is the epoch and its transaction input the network.
+func (s *BadgerStore) ReadNetworkParams() (uint64, crypto.Hash, error) {
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	snapshots, err := readSnapshotsSinceTopology(txn, 0, 1)
+	if err != nil {
+		return 0, crypto.Hash{}, err
+	}
+	if len(snapshots) != 1 || snapshots[0].TopologicalOrder != 0 {
+		return 0, crypto.Hash{}, fmt.Errorf("genesis not loaded")
+	}
+	snap := snapshots[0]
+	tx, err := readTransaction(txn, snap.SoleTransaction())
+	if err != nil || tx == nil {
+		return 0, crypto.Hash{}, fmt.Errorf("genesis transaction %s not found %v", snap.SoleTransaction(), err)
+	}
+	var networkId crypto.Hash
+	if len(tx.Inputs) != 1 || len(tx.Inputs[0].Genesis) != len(networkId) {
+		return 0, crypto.Hash{}, fmt.Errorf("invalid genesis transaction %s", tx.PayloadHash())
+	}
+	copy(networkId[:], tx.Inputs[0].Genesis)
+	return snap.Timestamp, networkId, nil
+}
+
 func (s *BadgerStore) CheckGenesisLoad(snapshots []*common.SnapshotWithTopologicalOrder) (bool, error) {
 	txn := s.snapshotsDB.NewTransaction(false)
 	defer txn.Discard()
@@ -31,6 +31,7 @@ const (
 	graphPrefixWorkSign          = "WORKVOTE"
 	graphPrefixWorkOffset        = "WORKCHECKPOINT"
 	graphPrefixWorkSnapshot      = "WORKSNAPSHOT"
+	graphPrefixWorkTimestamp     = "WORKTIMESTAMP"
 	graphPrefixSpaceCheckpoint   = "SPACECHECKPOINT"
 	graphPrefixSpaceQueue        = "SPACEQUEUE"
 	graphPrefixAssetInfo         = "ASSETINFO"
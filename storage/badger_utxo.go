@@ -14,12 +14,15 @@ func (s *BadgerStore) ReadUTXOKeys(hash crypto.Hash, index uint) (*common.UTXOKe
 	defer txn.Discard()
 
 	utxo, err := s.readUTXOLock(txn, hash, index)
-	if err != nil {
+	if err != nil || utxo == nil {
 		return nil, err
 	}
 	return &common.UTXOKeys{
-		Mask: utxo.Mask,
-		Keys: utxo.Keys,
+		Mask:   utxo.Mask,
+		Keys:   utxo.Keys,
+		Script: utxo.Script,
+		Asset:  utxo.Asset,
+		Amount: utxo.Amount,
 	}, nil
 }
 
@@ -50,6 +53,38 @@ func (s *BadgerStore) readUTXOLock(txn *badger.Txn, hash crypto.Hash, index uint
 	return common.UnmarshalUTXO(ival)
 }
 
+// IterateUTXOsByAsset calls fn for each unlocked UTXO of the asset, the
+// iteration stops at the first error returned by fn.
+func (s *BadgerStore) IterateUTXOsByAsset(asset crypto.Hash, fn func(*common.UTXO) error) error {
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(graphPrefixUTXO)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	for it.Seek(opts.Prefix); it.ValidForPrefix(opts.Prefix); it.Next() {
+		ival, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		utxo, err := common.UnmarshalUTXO(ival)
+		if err != nil {
+			return err
+		}
+		if utxo.Asset != asset || utxo.LockHash.HasValue() {
+			continue
+		}
+		err = fn(&utxo.UTXO)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *BadgerStore) LockUTXOs(inputs []*common.Input, tx crypto.Hash, fork bool) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
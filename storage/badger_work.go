@@ -20,6 +20,21 @@ func (s *BadgerStore) ReadWorkOffset(nodeId crypto.Hash) (uint64, error) {
 	return graphReadUint64(txn, offKey)
 }
 
+func (s *BadgerStore) ReadLastSnapshotTimestamp(nodeId crypto.Hash) (uint64, error) {
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	return graphReadUint64(txn, graphWorkTimestampKey(nodeId))
+}
+
+// WriteLastSnapshotTimestamp only moves the timestamp forward, an older or
+// equal timestamp is ignored without error, as WriteRoundWork does.
+func (s *BadgerStore) WriteLastSnapshotTimestamp(nodeId crypto.Hash, ts uint64) error {
+	return s.snapshotsDB.Update(func(txn *badger.Txn) error {
+		return graphWriteWorkTimestamp(txn, nodeId, ts)
+	})
+}
+
 func (s *BadgerStore) ReadSnapshotWorksForNodeRound(nodeId crypto.Hash, round uint64) ([]*common.SnapshotWork, error) {
 	txn := s.snapshotsDB.NewTransaction(false)
 	defer txn.Discard()
@@ -96,6 +111,46 @@ func (s *BadgerStore) ListNodeWorks(cids []crypto.Hash, day uint32) (map[crypto.
 	return works, nil
 }
 
+// NodeWorkActiveRange returns the first and last days the node has non-zero
+// lead or sign works.
+func (s *BadgerStore) NodeWorkActiveRange(id crypto.Hash) (uint32, uint32, error) {
+	txn := s.snapshotsDB.NewTransaction(false)
+	defer txn.Discard()
+
+	first, last, found := uint32(0), uint32(0), false
+	for _, p := range []string{graphPrefixWorkLead, graphPrefixWorkSign} {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = append([]byte(p), id[:]...)
+		it := txn.NewIterator(opts)
+		for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+			item := it.Item()
+			v, err := item.ValueCopy(nil)
+			if err != nil {
+				it.Close()
+				return 0, 0, err
+			}
+			if binary.BigEndian.Uint64(v) == 0 {
+				continue
+			}
+			day := binary.BigEndian.Uint32(item.Key()[len(opts.Prefix):])
+			if !found || day < first {
+				first = day
+			}
+			if !found || day > last {
+				last = day
+			}
+			found = true
+		}
+		it.Close()
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("no works for node %s", id)
+	}
+	return first, last, nil
+}
+
+// WriteRoundWork advances the work offset to round and credits the works in
+// the same transaction, so the offset never moves without the works.
 func (s *BadgerStore) WriteRoundWork(nodeId crypto.Hash, round uint64, snapshots []*common.SnapshotWork, credit bool) error {
 	return s.snapshotsDB.Update(func(txn *badger.Txn) error {
 		offKey := graphWorkOffsetKey(nodeId)
@@ -130,15 +185,25 @@ func (s *BadgerStore) WriteRoundWork(nodeId crypto.Hash, round uint64, snapshots
 		}
 
 		err = graphWriteWorkOffset(txn, offKey, round, snapshots)
-		if err != nil || len(fresh) == 0 {
+		if err != nil {
 			return err
 		}
+		var last uint64
+		for _, w := range snapshots {
+			last = max(last, w.Timestamp)
+		}
+		err = graphWriteWorkTimestamp(txn, nodeId, last)
+		if err != nil {
+			return err
+		}
+		if len(fresh) == 0 {
+			return nil
+		}
 		if len(fresh[0].Signers) == 0 || !credit {
 			return nil
 		}
 
 		day := uint32(fresh[0].Timestamp / DAY_U64)
-		wm := make(map[crypto.Hash]uint64)
 		for _, w := range fresh {
 			if w.Timestamp == 0 {
 				panic(w)
@@ -149,11 +214,9 @@ func (s *BadgerStore) WriteRoundWork(nodeId crypto.Hash, round uint64, snapshots
 			if !w.Hash.HasValue() {
 				panic(w)
 			}
-			for _, si := range w.Signers {
-				wm[si] += 1
-			}
 		}
-		if wm[nodeId] != uint64(len(fresh)) {
+		wm := AccumulateWorks(fresh, nodeId)
+		if wm[nodeId][0] != uint64(len(fresh)) || wm[nodeId][1] != 0 {
 			panic(nodeId)
 		}
 
@@ -166,7 +229,7 @@ func (s *BadgerStore) WriteRoundWork(nodeId crypto.Hash, round uint64, snapshots
 			if err != nil {
 				return err
 			}
-			err = graphWriteUint64(txn, signKey, os+wn)
+			err = graphWriteUint64(txn, signKey, os+wn[1])
 			if err != nil {
 				return err
 			}
@@ -177,10 +240,28 @@ func (s *BadgerStore) WriteRoundWork(nodeId crypto.Hash, round uint64, snapshots
 		if err != nil {
 			return err
 		}
-		return graphWriteUint64(txn, leadKey, ol+wm[nodeId])
+		return graphWriteUint64(txn, leadKey, ol+wm[nodeId][0])
 	})
 }
 
+// AccumulateWorks counts the works of the snapshots led by self, the lead
+// works of self, and the sign works of all other signers, as ListNodeWorks.
+func AccumulateWorks(snapshots []*common.SnapshotWork, self crypto.Hash) map[crypto.Hash][2]uint64 {
+	works := make(map[crypto.Hash][2]uint64)
+	for _, w := range snapshots {
+		for _, si := range w.Signers {
+			work := works[si]
+			if si == self {
+				work[0] += 1
+			} else {
+				work[1] += 1
+			}
+			works[si] = work
+		}
+	}
+	return works
+}
+
 func writeSnapshotWork(txn *badger.Txn, snap *common.SnapshotWithTopologicalOrder, signers []crypto.Hash) error {
 	key := graphWorkSnapshotKey(snap.NodeId, snap.RoundNumber, snap.Timestamp)
 	val := make([]byte, (1+len(signers))*32)
@@ -242,6 +323,16 @@ func graphReadWorkOffset(txn *badger.Txn, key []byte) (uint64, map[crypto.Hash]b
 	return round, snapshots, nil
 }
 
+// the timestamp only moves forward, works of a round could be written again
+func graphWriteWorkTimestamp(txn *badger.Txn, nodeId crypto.Hash, ts uint64) error {
+	key := graphWorkTimestampKey(nodeId)
+	old, err := graphReadUint64(txn, key)
+	if err != nil || old >= ts {
+		return err
+	}
+	return graphWriteUint64(txn, key, ts)
+}
+
 func graphWriteUint64(txn *badger.Txn, key []byte, val uint64) error {
 	buf := binary.BigEndian.AppendUint64(nil, val)
 	return txn.Set(key, buf)
@@ -266,6 +357,10 @@ func graphWorkOffsetKey(nodeId crypto.Hash) []byte {
 	return append([]byte(graphPrefixWorkOffset), nodeId[:]...)
 }
 
+func graphWorkTimestampKey(nodeId crypto.Hash) []byte {
+	return append([]byte(graphPrefixWorkTimestamp), nodeId[:]...)
+}
+
 func graphWorkSignKey(nodeId crypto.Hash, day uint32) []byte {
 	key := append([]byte(graphPrefixWorkSign), nodeId[:]...)
 	return binary.BigEndian.AppendUint32(key, day)
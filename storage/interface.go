@@ -10,6 +10,7 @@ type Store interface {
 
 	CheckGenesisLoad(snapshots []*common.SnapshotWithTopologicalOrder) (bool, error)
 	LoadGenesis(rounds []*common.Round, snapshots []*common.SnapshotWithTopologicalOrder, transactions []*common.VersionedTransaction) error
+	ReadNetworkParams() (uint64, crypto.Hash, error)
 	ReadAssetWithBalance(id crypto.Hash) (*common.Asset, common.Integer, error)
 	ReadAllNodes(threshold uint64, withState bool) []*common.Node
 	AddNodeOperation(tx *common.VersionedTransaction, timestamp, threshold uint64, finalized bool) error
@@ -23,8 +24,10 @@ type Store interface {
 
 	ReadUTXOKeys(hash crypto.Hash, index uint) (*common.UTXOKeys, error)
 	ReadUTXOLock(hash crypto.Hash, index uint) (*common.UTXOWithLock, error)
+	IterateUTXOsByAsset(asset crypto.Hash, fn func(*common.UTXO) error) error
 	LockUTXOs(inputs []*common.Input, tx crypto.Hash, fork bool) error
 	ReadDepositLock(deposit *common.DepositData) (crypto.Hash, error)
+	DepositExists(deposit *common.DepositData) (bool, error)
 	LockDepositInput(deposit *common.DepositData, tx crypto.Hash, fork bool) error
 	ReadWithdrawalClaim(hash crypto.Hash) (*common.VersionedTransaction, string, error)
 	ReadGhostKeyLock(key crypto.Key) (*crypto.Hash, error)
@@ -50,7 +53,10 @@ type Store interface {
 	ReadSnapshotWorksForNodeRound(nodeId crypto.Hash, round uint64) ([]*common.SnapshotWork, error)
 	ListWorkOffsets(cids []crypto.Hash) (map[crypto.Hash]uint64, error)
 	ListNodeWorks(cids []crypto.Hash, day uint32) (map[crypto.Hash][2]uint64, error)
+	NodeWorkActiveRange(id crypto.Hash) (uint32, uint32, error)
 	ReadWorkOffset(nodeId crypto.Hash) (uint64, error)
+	ReadLastSnapshotTimestamp(nodeId crypto.Hash) (uint64, error)
+	WriteLastSnapshotTimestamp(nodeId crypto.Hash, ts uint64) error
 	WriteRoundWork(nodeId crypto.Hash, round uint64, snapshots []*common.SnapshotWork, credit bool) error
 
 	ReadRoundSpaceCheckpoint(nodeId crypto.Hash) (uint64, uint64, error)
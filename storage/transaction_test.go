@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -29,8 +30,14 @@ func TestTransaction(t *testing.T) {
 	loaded, err := store.CheckGenesisLoad(snapshots)
 	require.Nil(err)
 	require.False(loaded)
+	_, _, err = store.ReadNetworkParams()
+	require.NotNil(err)
 	err = store.LoadGenesis(rounds, snapshots, transactions)
 	require.Nil(err)
+	epoch, networkId, err := store.ReadNetworkParams()
+	require.Nil(err)
+	require.Equal(gns.EpochTimestamp(), epoch)
+	require.Equal(gns.NetworkId(), networkId)
 	loaded, err = store.CheckGenesisLoad(snapshots)
 	require.Nil(err)
 	require.True(loaded)
@@ -74,6 +81,15 @@ func TestTransaction(t *testing.T) {
 	utxo, err := store.ReadUTXOLock(deposit.AsVersioned().PayloadHash(), 0)
 	require.Nil(err)
 	require.Nil(utxo)
+	keys, err := store.ReadUTXOKeys(deposit.AsVersioned().PayloadHash(), 0)
+	require.Nil(err)
+	require.Nil(keys)
+	spend := common.NewTransactionV5(common.XINAssetId)
+	spend.AddInput(deposit.AsVersioned().PayloadHash(), 1)
+	spend.AddInput(deposit.AsVersioned().PayloadHash(), 0)
+	spent, err := spend.AsVersioned().CheckInputsUnspent(store)
+	require.Nil(err)
+	require.Equal([]int{0, 1}, spent)
 	_, balance, err = store.ReadAssetWithBalance(common.XINAssetId)
 	require.Nil(err)
 	require.Equal("365553.00000000", balance.String())
@@ -96,11 +112,29 @@ func TestTransaction(t *testing.T) {
 		Snapshot:         snap,
 		TopologicalOrder: uint64(len(snapshots)),
 	}
+	exists, err := store.DepositExists(deposit.Inputs[0].Deposit)
+	require.Nil(err)
+	require.False(exists)
 	err = store.WriteSnapshot(topo, signers)
 	require.Nil(err)
+	exists, err = store.DepositExists(deposit.Inputs[0].Deposit)
+	require.Nil(err)
+	require.True(exists)
+	other := *deposit.Inputs[0].Deposit
+	other.Index = 1
+	exists, err = store.DepositExists(&other)
+	require.Nil(err)
+	require.False(exists)
 	utxo, err = store.ReadUTXOLock(deposit.AsVersioned().PayloadHash(), 0)
 	require.Nil(err)
 	require.NotNil(utxo)
+	spent, err = spend.AsVersioned().CheckInputsUnspent(store)
+	require.Nil(err)
+	require.Len(spent, 0)
+	spend.AddInput(deposit.AsVersioned().PayloadHash(), 2)
+	spent, err = spend.AsVersioned().CheckInputsUnspent(store)
+	require.Nil(err)
+	require.Equal([]int{2}, spent)
 	_, balance, err = store.ReadAssetWithBalance(common.XINAssetId)
 	require.Nil(err)
 	require.Equal("365563.00000000", balance.String())
@@ -115,8 +149,32 @@ func TestTransaction(t *testing.T) {
 			Tag:     "21BTC",
 		},
 	}}
+	deposits := func() []uint {
+		var indexes []uint
+		err := store.IterateUTXOsByAsset(common.XINAssetId, func(u *common.UTXO) error {
+			if u.Hash == deposit.AsVersioned().PayloadHash() {
+				indexes = append(indexes, u.Index)
+			}
+			return nil
+		})
+		require.Nil(err)
+		return indexes
+	}
+	require.Equal([]uint{0, 1}, deposits())
+	err = store.IterateUTXOsByAsset(common.BitcoinAssetId, func(u *common.UTXO) error {
+		return fmt.Errorf("unexpected asset %s", u.Asset)
+	})
+	require.Nil(err)
+	var visited int
+	err = store.IterateUTXOsByAsset(common.XINAssetId, func(u *common.UTXO) error {
+		visited += 1
+		return fmt.Errorf("stop %d", visited)
+	})
+	require.Equal("stop 1", err.Error())
+	require.Equal(1, visited)
 	err = store.LockUTXOs(submit.Inputs, submit.AsVersioned().PayloadHash(), false)
 	require.Nil(err)
+	require.Equal([]uint{1}, deposits())
 	err = store.WriteTransaction(submit.AsVersioned())
 	require.Nil(err)
 	_, balance, err = store.ReadAssetWithBalance(common.XINAssetId)